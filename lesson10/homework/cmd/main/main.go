@@ -2,16 +2,30 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
+	"flag"
 	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
-	"homework10/internal/adapters/adfilters"
+	"google.golang.org/grpc/credentials"
 	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/blobfs"
+	"homework10/internal/adapters/chatrepo"
+	"homework10/internal/adapters/favrepo"
+	"homework10/internal/adapters/pgrepo"
 	"homework10/internal/adapters/userrepo"
 	"homework10/internal/app"
+	"homework10/internal/auth"
+	"homework10/internal/config"
+	"homework10/internal/health"
+	"homework10/internal/logging"
+	"homework10/internal/metrics"
 	grpcPorts "homework10/internal/ports/grpc"
 	"homework10/internal/ports/httpgin"
+	"homework10/internal/tracing"
 	"log"
 	"net"
 	"net/http"
@@ -21,25 +35,120 @@ import (
 	"time"
 )
 
-const (
-	grpcPort = ":8080"
-	httpPort = ":18080"
-)
-const httpShutdownTime = 15 * time.Second
+// newApp добавляет в checker проверку связи с выбранным хранилищем.
+func newApp(ctx context.Context, storage, dsn string, logger *zap.Logger, checker *health.Checker, opts ...app.Option) (app.App, func(), error) {
+	switch storage {
+	case config.StorageMemory:
+		return app.NewApp(adrepo.New(), userrepo.New(), favrepo.New(), chatrepo.New(), opts...), func() {}, nil
+	case config.StoragePostgres:
+		pool, err := pgrepo.NewPool(ctx, dsn, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		checker.AddCheck("postgres", pool.Ping)
+		return app.NewApp(pgrepo.New(pool), pgrepo.NewUsers(pool), pgrepo.NewFavorites(pool), pgrepo.NewChats(pool), opts...), pool.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage %q", storage)
+	}
+}
 
 func main() {
-	lis, err := net.Listen("tcp", grpcPort)
+	printConfig := flag.Bool("print-config", false, "print the resulting configuration with secrets hidden and exit; exits with an error if it is invalid")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.LookupEnv)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if *printConfig {
+		os.Exit(printConfiguration(cfg))
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
 	}
 
-	a := app.NewApp(adrepo.New(), userrepo.New(), adfilters.New())
+	logger, err := logging.New(cfg.Log.Level)
+	if err != nil {
+		log.Fatalf("failed to init logger: %v", err)
+	}
+	defer func() { _ = logger.Sync() }()
 
-	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPorts.UnaryInterceptor, grpcPorts.RecoveryInterceptor))
-	grpcService := grpcPorts.NewService(a)
+	if cfg.Tracing.OTLPEndpoint != "" {
+		tp, err := tracing.NewProvider(context.Background(), cfg.Tracing.OTLPEndpoint, "ads")
+		if err != nil {
+			logger.Fatal("failed to init tracing", zap.Error(err))
+		}
+		otel.SetTracerProvider(tp)
+		defer func() {
+			shCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+			defer cancel()
+			if err := tp.Shutdown(shCtx); err != nil {
+				logger.Error("can't flush traces", zap.Error(err))
+			}
+		}()
+	}
+
+	blobs, err := blobfs.New(cfg.Storage.BlobDir)
+	if err != nil {
+		logger.Fatal("failed to init blob store", zap.Error(err))
+	}
+
+	tokens, err := newTokens(cfg.Auth, logger)
+	if err != nil {
+		logger.Fatal("failed to init tokens", zap.Error(err))
+	}
+
+	opts := []app.Option{app.WithModerators(cfg.Auth.Moderators...), app.WithAdmins(cfg.Auth.Admins...), app.WithBlobStore(blobs), app.WithLogger(logger)}
+	if cfg.Auth.ImportUsers {
+		opts = append(opts, app.WithUserImport())
+	}
+	checker := health.New()
+	a, closeStorage, err := newApp(context.Background(), cfg.Storage.Backend, cfg.Storage.PostgresDSN, logger, checker, opts...)
+	if err != nil {
+		logger.Fatal("failed to init storage", zap.Error(err))
+	}
+	defer closeStorage()
+
+	lis, err := net.Listen("tcp", cfg.Server.GRPCAddr)
+	if err != nil {
+		logger.Fatal("failed to listen", zap.Error(err))
+	}
+
+	var grpcOpts []grpc.ServerOption
+	if cfg.Server.TLS.Enabled() {
+		creds, err := credentials.NewServerTLSFromFile(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+		if err != nil {
+			logger.Fatal("failed to load tls certificate", zap.Error(err))
+		}
+		grpcOpts = append(grpcOpts, grpc.Creds(creds))
+	}
+
+	m := metrics.New()
+	m.RegisterApp(a)
+
+	grpcServer := grpc.NewServer(append(grpcOpts, grpc.ChainUnaryInterceptor(
+		grpcPorts.TracingInterceptor,
+		grpcPorts.UnaryInterceptor(logger),
+		grpcPorts.MetricsInterceptor(m),
+		grpcPorts.RecoveryInterceptor(logger),
+		grpcPorts.AuthInterceptor(tokens),
+	), grpc.ChainStreamInterceptor(
+		grpcPorts.StreamTracingInterceptor,
+		grpcPorts.StreamInterceptor(logger),
+		grpcPorts.StreamMetricsInterceptor(m),
+		grpcPorts.StreamRecoveryInterceptor(logger),
+		grpcPorts.StreamAuthInterceptor(tokens),
+	))...)
+	grpcService := grpcPorts.NewService(a, tokens)
 	grpcPorts.RegisterAdServiceServer(grpcServer, grpcService)
+	checker.RegisterGRPC(grpcServer, grpcPorts.AdService_ServiceDesc.ServiceName)
+
+	httpServer := httpgin.NewHTTPServer(cfg.Server.HTTPAddr, a, tokens, logger, m)
 
-	httpServer := httpgin.NewHTTPServer(httpPort, a)
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/metrics", m.Handler())
+	adminMux.Handle("/healthz", checker.Healthz())
+	adminMux.Handle("/readyz", checker.Readyz())
+	// Служебный сервер работает без TLS; наружу его открывать не нужно
+	adminServer := &http.Server{Addr: cfg.Server.AdminAddr, Handler: adminMux}
 
 	eg, ctx := errgroup.WithContext(context.Background())
 
@@ -50,16 +159,38 @@ func main() {
 	eg.Go(func() error {
 		select {
 		case s := <-sigQuit:
-			log.Printf("captured signal: %v\n", s)
+			logger.Info("captured signal", zap.Stringer("signal", s))
+			// Серверы останавливаются только после того, как сервис побыл неготовым
+			// drain: за это время балансировщик перестаёт присылать новые запросы.
+			// Повторный сигнал прерывает ожидание.
+			checker.Shutdown()
+			select {
+			case <-time.After(cfg.Server.ShutdownDelay):
+			case <-sigQuit:
+			}
 			return fmt.Errorf("captured signal: %v", s)
 		case <-ctx.Done():
+			checker.Shutdown()
 			return nil
 		}
 	})
 
 	eg.Go(func() error {
-		log.Printf("starting grpc server, listening on %s\n", grpcPort)
-		defer log.Printf("close grpc server listening on %s\n", grpcPort)
+		app.RunPurge(ctx, a, cfg.Purge.Interval, cfg.Purge.Retention, func(p app.Purged, err error) {
+			if err != nil {
+				logger.Error("can't purge deleted records", zap.Error(err))
+				return
+			}
+			if p.Ads > 0 || p.Users > 0 {
+				logger.Info("purged deleted records", zap.Int64("ads", p.Ads), zap.Int64("users", p.Users))
+			}
+		})
+		return nil
+	})
+
+	eg.Go(func() error {
+		logger.Info("starting grpc server", zap.String("addr", cfg.Server.GRPCAddr), zap.Bool("tls", cfg.Server.TLS.Enabled()))
+		defer logger.Info("close grpc server", zap.String("addr", cfg.Server.GRPCAddr))
 
 		errCh := make(chan error)
 
@@ -85,39 +216,85 @@ func main() {
 	})
 
 	eg.Go(func() error {
-		log.Printf("starting http server, listening on %s\n", httpServer.Addr)
-		defer log.Printf("close http server listening on %s\n", httpServer.Addr)
+		return serveHTTP(ctx, logger, "http", httpServer, cfg.Server.TLS, cfg.Server.ShutdownTimeout)
+	})
 
-		errCh := make(chan error)
+	eg.Go(func() error {
+		return serveHTTP(ctx, logger, "admin", adminServer, config.TLS{}, cfg.Server.ShutdownTimeout)
+	})
 
-		defer func() {
-			shCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTime)
-			defer cancel()
+	if err := eg.Wait(); err != nil {
+		logger.Info("gracefully shutting down the servers", zap.Error(err))
+	}
 
-			if err := httpServer.Shutdown(shCtx); err != nil {
-				log.Printf("can't close http server listening on %s: %s", httpServer.Addr, err.Error())
-			}
+	logger.Info("servers were successfully shutdown")
+}
 
-			close(errCh)
-		}()
+// serveHTTP обслуживает запросы до отмены ctx, после чего останавливает сервер,
+// давая текущим запросам shutdownTimeout на завершение.
+func serveHTTP(ctx context.Context, logger *zap.Logger, name string, srv *http.Server, tls config.TLS, shutdownTimeout time.Duration) error {
+	logger.Info("starting "+name+" server", zap.String("addr", srv.Addr), zap.Bool("tls", tls.Enabled()))
+	defer logger.Info("close "+name+" server", zap.String("addr", srv.Addr))
 
-		go func() {
-			if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
-				errCh <- err
-			}
-		}()
+	errCh := make(chan error)
 
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case err := <-errCh:
-			return fmt.Errorf("http server can't listen and serve requests: %w", err)
+	defer func() {
+		shCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shCtx); err != nil {
+			logger.Error("can't close "+name+" server", zap.String("addr", srv.Addr), zap.Error(err))
 		}
-	})
 
-	if err := eg.Wait(); err != nil {
-		log.Printf("gracefully shutting down the servers: %s\n", err.Error())
+		close(errCh)
+	}()
+
+	go func() {
+		var err error
+		if tls.Enabled() {
+			err = srv.ListenAndServeTLS(tls.CertFile, tls.KeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-errCh:
+		return fmt.Errorf("%s server can't listen and serve requests: %w", name, err)
 	}
+}
 
-	log.Println("servers were successfully shutdown")
+// newTokens без заданного секрета генерирует случайный: выданные токены
+// перестанут действовать после перезапуска.
+func newTokens(cfg config.Auth, logger *zap.Logger) (*auth.Tokens, error) {
+	if cfg.Secret != "" {
+		return auth.NewTokens([]byte(cfg.Secret), cfg.TokenTTL), nil
+	}
+	logger.Warn("auth secret is not set, generating a random one")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return auth.NewTokens(key, cfg.TokenTTL), nil
+}
+
+// printConfiguration выводит конфигурацию для --print-config и возвращает код выхода:
+// конфигурация печатается, даже если она неверна, чтобы было видно, откуда ошибка.
+func printConfiguration(cfg config.Config) int {
+	out, err := cfg.Redacted().YAML()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't print config: %v\n", err)
+		return 1
+	}
+	fmt.Print(string(out))
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
 }
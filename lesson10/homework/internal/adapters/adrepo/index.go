@@ -0,0 +1,85 @@
+package adrepo
+
+import (
+	"math"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+// index - инвертированный индекс: слово -> ID объявления -> вес слова в объявлении.
+// Вес складывается из числа вхождений в текст и app.TitleWeight за каждое вхождение в заголовок.
+// Не потокобезопасен, защищается мьютексом Repo.
+type index struct {
+	terms map[string]map[int64]float64
+	docs  map[int64][]string
+}
+
+func newIndex() *index {
+	return &index{
+		terms: map[string]map[int64]float64{},
+		docs:  map[int64][]string{},
+	}
+}
+
+func (ix *index) put(ad ads.Ad) {
+	ix.remove(ad.ID)
+
+	weights := map[string]float64{}
+	for _, term := range app.Tokenize(ad.Title) {
+		weights[term] += app.TitleWeight
+	}
+	for _, term := range app.Tokenize(ad.Text) {
+		weights[term]++
+	}
+
+	terms := make([]string, 0, len(weights))
+	for term, w := range weights {
+		if ix.terms[term] == nil {
+			ix.terms[term] = map[int64]float64{}
+		}
+		ix.terms[term][ad.ID] = w
+		terms = append(terms, term)
+	}
+	ix.docs[ad.ID] = terms
+}
+
+func (ix *index) remove(adID int64) {
+	for _, term := range ix.docs[adID] {
+		delete(ix.terms[term], adID)
+		if len(ix.terms[term]) == 0 {
+			delete(ix.terms, term)
+		}
+	}
+	delete(ix.docs, adID)
+}
+
+// scores возвращает TF-IDF релевантность объявлений, содержащих все слова запроса.
+func (ix *index) scores(query string) map[int64]float64 {
+	terms := app.Tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	total := float64(len(ix.docs))
+	var res map[int64]float64
+	for _, term := range terms {
+		postings := ix.terms[term]
+		idf := math.Log(1 + total/float64(len(postings)+1))
+
+		next := map[int64]float64{}
+		for adID, w := range postings {
+			if res != nil {
+				if _, ok := res[adID]; !ok {
+					continue
+				}
+			}
+			next[adID] = res[adID] + w*idf
+		}
+		res = next
+		if len(res) == 0 {
+			break
+		}
+	}
+	return res
+}
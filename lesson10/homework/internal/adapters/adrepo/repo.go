@@ -2,6 +2,7 @@ package adrepo
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,29 +11,38 @@ import (
 )
 
 type Repo struct {
-	mx *sync.RWMutex
-	mp map[int64]ads.Ad
-	ID int64
+	mx        *sync.RWMutex
+	mp        map[int64]ads.Ad
+	revisions map[int64][]ads.Revision
+	index     *index
+	ID        int64
 }
 
 func New() app.Repository {
 	return &Repo{
-		mx: &sync.RWMutex{},
-		mp: map[int64]ads.Ad{},
+		mx:        &sync.RWMutex{},
+		mp:        map[int64]ads.Ad{},
+		revisions: map[int64][]ads.Revision{},
+		index:     newIndex(),
 	}
 }
 
-func (r *Repo) Find(ctx context.Context, adID int64) (ads.Ad, bool) {
+func (r *Repo) Find(ctx context.Context, adID int64) (ads.Ad, error) {
 	r.mx.Lock()
 	defer r.mx.Unlock()
-	_, ok := r.mp[adID]
-	if !ok {
-		return ads.Ad{}, false
+	return r.find(adID)
+}
+
+// find возвращает неудалённое объявление; вызывается под r.mx.
+func (r *Repo) find(adID int64) (ads.Ad, error) {
+	ad, ok := r.mp[adID]
+	if !ok || ad.DeletedAt != nil {
+		return ads.Ad{}, app.ErrNotFound
 	}
-	return r.mp[adID], true
+	return ad, nil
 }
 
-func (r *Repo) Add(ctx context.Context, title string, text string, userID int64) ads.Ad {
+func (r *Repo) Add(ctx context.Context, title string, text string, attrs ads.Attributes, userID int64) (ads.Ad, error) {
 	r.mx.Lock()
 	defer r.mx.Unlock()
 	for {
@@ -46,71 +56,212 @@ func (r *Repo) Add(ctx context.Context, title string, text string, userID int64)
 		Title:        title,
 		Text:         text,
 		AuthorID:     userID,
+		Attributes:   attrs,
 		Published:    false,
+		Status:       ads.StatusDraft,
+		Version:      1,
 		CreationDate: time.Now().UTC(),
 		UpdateDate:   time.Now().UTC(),
 	}
-	return r.mp[r.ID]
+	r.index.put(r.mp[r.ID])
+	r.addRevision(r.mp[r.ID])
+	return r.mp[r.ID], nil
 }
 
-func (r *Repo) ChangeTitle(ctx context.Context, adID int64, title string) ads.Ad {
+func (r *Repo) Update(ctx context.Context, adID int64, mutate func(ad *ads.Ad) error) (ads.Ad, error) {
 	r.mx.Lock()
 	defer r.mx.Unlock()
-	ad := r.mp[adID]
-	ad.Title = title
+	old, err := r.find(adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	ad := old
+	if err := mutate(&ad); err != nil {
+		return ads.Ad{}, err
+	}
+	ad.ID, ad.AuthorID, ad.CreationDate = old.ID, old.AuthorID, old.CreationDate
+	ad.Published = ad.Status == ads.StatusPublished
+	ad.Version = old.Version + 1
 	ad.UpdateDate = time.Now().UTC()
 	r.mp[adID] = ad
-	return r.mp[adID]
-
+	r.index.put(ad)
+	if ad.Title != old.Title || ad.Text != old.Text {
+		r.addRevision(ad)
+	}
+	return ad, nil
 }
 
-func (r *Repo) ChangeText(ctx context.Context, adID int64, text string) ads.Ad {
+func (r *Repo) GetAdsByFilter(ctx context.Context, filter app.Filter, page app.Page) ([]ads.Ad, error) {
 	r.mx.Lock()
 	defer r.mx.Unlock()
-	ad := r.mp[adID]
-	ad.Text = text
-	ad.UpdateDate = time.Now().UTC()
-	r.mp[adID] = ad
-	return r.mp[adID]
+	return r.selectPage(page, filter.Match), nil
 }
 
-func (r *Repo) ChangeStatus(ctx context.Context, adID int64, status bool) ads.Ad {
+func (r *Repo) GetByTitle(ctx context.Context, title string, page app.Page) ([]ads.Ad, error) {
 	r.mx.Lock()
 	defer r.mx.Unlock()
-	ad := r.mp[adID]
-	ad.Published = status
-	ad.UpdateDate = time.Now().UTC()
-	r.mp[adID] = ad
-	return r.mp[adID]
+	return r.selectPage(page, func(ad ads.Ad) bool {
+		return ad.Title == title && ad.DeletedAt == nil
+	}), nil
 }
 
-func (r *Repo) GetAdsByFilter(ctx context.Context, filter app.Filter) ([]ads.Ad, error) {
+func (r *Repo) Search(ctx context.Context, query string, filter app.Filter, limit int) ([]app.SearchResult, error) {
 	r.mx.Lock()
 	defer r.mx.Unlock()
+
+	results := []app.SearchResult{}
+	for adID, score := range r.index.scores(query) {
+		ad := r.mp[adID]
+		if filter.Match(ad) {
+			results = append(results, app.SearchResult{Ad: ad, Score: score})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Ad.ID < results[j].Ad.ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// selectPage возвращает до page.Limit+1 подходящих объявлений после курсора страницы.
+func (r *Repo) selectPage(page app.Page, match func(ads.Ad) bool) []ads.Ad {
 	adss := []ads.Ad{}
 	for _, ad := range r.mp {
-		if app.CheckAd(ad, filter) {
+		if match(ad) && page.Follows(ad) {
 			adss = append(adss, ad)
 		}
 	}
-	return adss, nil
+	sort.Slice(adss, func(i, j int) bool {
+		return page.Less(adss[i], adss[j])
+	})
+	if len(adss) > page.Limit+1 {
+		adss = adss[:page.Limit+1]
+	}
+	return adss
+}
+
+func (r *Repo) Delete(ctx context.Context, adID int64, check func(ad ads.Ad) error) (ads.Ad, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	ad, err := r.find(adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	if err := check(ad); err != nil {
+		return ads.Ad{}, err
+	}
+	now := time.Now().UTC()
+	ad.DeletedAt = &now
+	r.mp[adID] = ad
+	return ad, nil
+}
+
+func (r *Repo) Restore(ctx context.Context, adID int64, check func(ad ads.Ad) error) (ads.Ad, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	ad, ok := r.mp[adID]
+	if !ok || ad.DeletedAt == nil {
+		return ads.Ad{}, app.ErrNotFound
+	}
+	if err := check(ad); err != nil {
+		return ads.Ad{}, err
+	}
+	ad.DeletedAt = nil
+	r.mp[adID] = ad
+	return ad, nil
 }
 
-func (r *Repo) GetByTitle(ctx context.Context, title string) []ads.Ad {
+func (r *Repo) DeleteByAuthor(ctx context.Context, authorID int64, at time.Time) ([]ads.Ad, error) {
 	r.mx.Lock()
 	defer r.mx.Unlock()
+	return r.setDeleted(func(ad ads.Ad) bool {
+		return ad.AuthorID == authorID && ad.DeletedAt == nil
+	}, &at), nil
+}
+
+func (r *Repo) RestoreByAuthor(ctx context.Context, authorID int64, at time.Time) ([]ads.Ad, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.setDeleted(func(ad ads.Ad) bool {
+		return ad.AuthorID == authorID && ad.DeletedAt != nil && ad.DeletedAt.Equal(at)
+	}, nil), nil
+}
+
+// setDeleted выставляет DeletedAt подходящим объявлениям и возвращает их по возрастанию ID;
+// вызывается под r.mx.
+func (r *Repo) setDeleted(match func(ads.Ad) bool, deletedAt *time.Time) []ads.Ad {
 	adss := []ads.Ad{}
-	for _, ad := range r.mp {
-		if ad.Title == title {
-			adss = append(adss, ad)
+	for id, ad := range r.mp {
+		if !match(ad) {
+			continue
 		}
+		ad.DeletedAt = deletedAt
+		r.mp[id] = ad
+		adss = append(adss, ad)
 	}
+	sort.Slice(adss, func(i, j int) bool {
+		return adss[i].ID < adss[j].ID
+	})
 	return adss
 }
 
-func (r *Repo) Delete(ctx context.Context, adID int64) error {
+func (r *Repo) Purge(ctx context.Context, before time.Time) ([]ads.Ad, error) {
 	r.mx.Lock()
 	defer r.mx.Unlock()
-	delete(r.mp, adID)
-	return nil
+	adss := []ads.Ad{}
+	for id, ad := range r.mp {
+		if ad.DeletedAt == nil || !ad.DeletedAt.Before(before) {
+			continue
+		}
+		delete(r.mp, id)
+		delete(r.revisions, id)
+		r.index.remove(id)
+		adss = append(adss, ad)
+	}
+	return adss, nil
+}
+
+// addRevision сохраняет содержимое объявления как его следующую версию; вызывается под r.mx.
+func (r *Repo) addRevision(ad ads.Ad) {
+	r.revisions[ad.ID] = append(r.revisions[ad.ID], ads.Revision{
+		AdID:         ad.ID,
+		Number:       int64(len(r.revisions[ad.ID]) + 1),
+		Title:        ad.Title,
+		Text:         ad.Text,
+		EditorID:     ad.AuthorID,
+		CreationDate: ad.UpdateDate,
+	})
+}
+
+func (r *Repo) Revisions(ctx context.Context, adID int64) ([]ads.Revision, error) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	return append([]ads.Revision(nil), r.revisions[adID]...), nil
+}
+
+func (r *Repo) Revision(ctx context.Context, adID int64, number int64) (ads.Revision, error) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	revs := r.revisions[adID]
+	if number < 1 || number > int64(len(revs)) {
+		return ads.Revision{}, app.ErrNotFound
+	}
+	return revs[number-1], nil
+}
+
+func (r *Repo) CountByStatus(ctx context.Context) (map[ads.Status]int64, error) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	counts := map[ads.Status]int64{}
+	for _, ad := range r.mp {
+		if ad.DeletedAt == nil {
+			counts[ad.Status]++
+		}
+	}
+	return counts, nil
 }
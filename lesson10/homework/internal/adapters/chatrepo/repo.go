@@ -0,0 +1,133 @@
+package chatrepo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"homework10/internal/app"
+	"homework10/internal/chat"
+)
+
+type threadKey struct {
+	adID, buyerID int64
+}
+
+type Repo struct {
+	mx      *sync.Mutex
+	threads map[int64]chat.Thread
+	byKey   map[threadKey]int64
+	// messages хранит сообщения каждой переписки в порядке отправки
+	messages map[int64][]chat.Message
+	threadID int64
+	msgID    int64
+}
+
+func New() app.Chats {
+	return &Repo{
+		mx:       &sync.Mutex{},
+		threads:  map[int64]chat.Thread{},
+		byKey:    map[threadKey]int64{},
+		messages: map[int64][]chat.Message{},
+	}
+}
+
+func (r *Repo) OpenThread(ctx context.Context, adID, buyerID, sellerID int64) (chat.Thread, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	key := threadKey{adID: adID, buyerID: buyerID}
+	if id, ok := r.byKey[key]; ok {
+		return r.threads[id], nil
+	}
+	r.threadID++
+	now := time.Now().UTC()
+	thread := chat.Thread{
+		ID:           r.threadID,
+		AdID:         adID,
+		BuyerID:      buyerID,
+		SellerID:     sellerID,
+		CreationDate: now,
+		UpdateDate:   now,
+	}
+	r.threads[thread.ID] = thread
+	r.byKey[key] = thread.ID
+	return thread, nil
+}
+
+func (r *Repo) Thread(ctx context.Context, threadID int64) (chat.Thread, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	thread, ok := r.threads[threadID]
+	if !ok {
+		return chat.Thread{}, app.ErrNotFound
+	}
+	return thread, nil
+}
+
+func (r *Repo) AddMessage(ctx context.Context, threadID, senderID int64, text string) (chat.Message, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	thread, ok := r.threads[threadID]
+	if !ok {
+		return chat.Message{}, app.ErrNotFound
+	}
+	r.msgID++
+	msg := chat.Message{
+		ID:           r.msgID,
+		ThreadID:     threadID,
+		SenderID:     senderID,
+		Text:         text,
+		CreationDate: time.Now().UTC(),
+	}
+	r.messages[threadID] = append(r.messages[threadID], msg)
+	thread.UpdateDate = msg.CreationDate
+	r.threads[threadID] = thread
+	return msg, nil
+}
+
+func (r *Repo) Messages(ctx context.Context, threadID int64) ([]chat.Message, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return append([]chat.Message{}, r.messages[threadID]...), nil
+}
+
+func (r *Repo) MarkRead(ctx context.Context, threadID, readerID, upTo int64, at time.Time) ([]chat.Message, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	marked := []chat.Message{}
+	for i, msg := range r.messages[threadID] {
+		if msg.SenderID == readerID || msg.ReadAt != nil || (upTo != app.ReadAll && msg.ID > upTo) {
+			continue
+		}
+		readAt := at
+		msg.ReadAt = &readAt
+		r.messages[threadID][i] = msg
+		marked = append(marked, msg)
+	}
+	return marked, nil
+}
+
+func (r *Repo) Inbox(ctx context.Context, userID int64) ([]chat.Summary, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	res := []chat.Summary{}
+	for _, thread := range r.threads {
+		msgs := r.messages[thread.ID]
+		if !thread.Participant(userID) || len(msgs) == 0 {
+			continue
+		}
+		summary := chat.Summary{Thread: thread, LastMessage: msgs[len(msgs)-1]}
+		for _, msg := range msgs {
+			if msg.SenderID != userID && msg.ReadAt == nil {
+				summary.Unread++
+			}
+		}
+		res = append(res, summary)
+	}
+	// Последние сообщения упорядочены по ID так же, как по времени
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LastMessage.ID > res[j].LastMessage.ID
+	})
+	return res, nil
+}
@@ -0,0 +1,82 @@
+package favrepo
+
+import (
+	"context"
+	"sync"
+
+	"homework10/internal/app"
+)
+
+type Repo struct {
+	mx *sync.Mutex
+	// mp хранит ID избранных объявлений каждого пользователя в порядке добавления
+	mp map[int64][]int64
+}
+
+func New() app.Favorites {
+	return &Repo{
+		mx: &sync.Mutex{},
+		mp: map[int64][]int64{},
+	}
+}
+
+func (r *Repo) Add(ctx context.Context, userID, adID int64) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if index(r.mp[userID], adID) >= 0 {
+		return nil
+	}
+	r.mp[userID] = append(r.mp[userID], adID)
+	return nil
+}
+
+func (r *Repo) Remove(ctx context.Context, userID, adID int64) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	i := index(r.mp[userID], adID)
+	if i < 0 {
+		return app.ErrNotFound
+	}
+	r.remove(userID, i)
+	return nil
+}
+
+func (r *Repo) List(ctx context.Context, userID int64) ([]int64, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	ids := r.mp[userID]
+	res := make([]int64, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		res = append(res, ids[i])
+	}
+	return res, nil
+}
+
+func (r *Repo) RemoveAd(ctx context.Context, adID int64) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	for userID, ids := range r.mp {
+		if i := index(ids, adID); i >= 0 {
+			r.remove(userID, i)
+		}
+	}
+	return nil
+}
+
+// remove убирает i-й элемент избранного пользователя; вызывается под r.mx.
+func (r *Repo) remove(userID int64, i int) {
+	ids := r.mp[userID]
+	r.mp[userID] = append(ids[:i], ids[i+1:]...)
+	if len(r.mp[userID]) == 0 {
+		delete(r.mp, userID)
+	}
+}
+
+func index(ids []int64, adID int64) int {
+	for i, id := range ids {
+		if id == adID {
+			return i
+		}
+	}
+	return -1
+}
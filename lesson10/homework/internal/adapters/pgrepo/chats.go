@@ -0,0 +1,187 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"homework10/internal/app"
+	"homework10/internal/chat"
+)
+
+type ChatRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewChats(pool *pgxpool.Pool) app.Chats {
+	return &ChatRepo{pool: pool}
+}
+
+const (
+	threadColumns  = `id, ad_id, buyer_id, seller_id, creation_date, update_date`
+	messageColumns = `id, thread_id, sender_id, text, read_at, creation_date`
+)
+
+func scanThread(row pgx.Row, extra ...any) (chat.Thread, error) {
+	var t chat.Thread
+	dest := []any{&t.ID, &t.AdID, &t.BuyerID, &t.SellerID, &t.CreationDate, &t.UpdateDate}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return chat.Thread{}, err
+	}
+	t.CreationDate = t.CreationDate.UTC()
+	t.UpdateDate = t.UpdateDate.UTC()
+	return t, nil
+}
+
+func scanMessage(row pgx.Row) (chat.Message, error) {
+	var m chat.Message
+	if err := row.Scan(&m.ID, &m.ThreadID, &m.SenderID, &m.Text, &m.ReadAt, &m.CreationDate); err != nil {
+		return chat.Message{}, err
+	}
+	m.CreationDate = m.CreationDate.UTC()
+	if m.ReadAt != nil {
+		readAt := m.ReadAt.UTC()
+		m.ReadAt = &readAt
+	}
+	return m, nil
+}
+
+func collectMessages(rows pgx.Rows) ([]chat.Message, error) {
+	defer rows.Close()
+
+	msgs := []chat.Message{}
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan message: %w", err)
+		}
+		msgs = append(msgs, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read messages: %w", err)
+	}
+	return msgs, nil
+}
+
+// Пустое изменение при конфликте нужно, чтобы RETURNING вернул уже существующую переписку
+const openThreadQuery = `INSERT INTO threads (ad_id, buyer_id, seller_id, creation_date, update_date)
+VALUES ($1, $2, $3, $4, $4)
+ON CONFLICT (ad_id, buyer_id) DO UPDATE SET ad_id = excluded.ad_id
+RETURNING ` + threadColumns
+
+func (r *ChatRepo) OpenThread(ctx context.Context, adID, buyerID, sellerID int64) (chat.Thread, error) {
+	thread, err := scanThread(r.pool.QueryRow(ctx, openThreadQuery, adID, buyerID, sellerID, time.Now().UTC()))
+	if err != nil {
+		return chat.Thread{}, fmt.Errorf("can't open thread for ad %d: %w", adID, err)
+	}
+	return thread, nil
+}
+
+const findThreadQuery = `SELECT ` + threadColumns + ` FROM threads WHERE id = $1`
+
+func (r *ChatRepo) Thread(ctx context.Context, threadID int64) (chat.Thread, error) {
+	thread, err := scanThread(r.pool.QueryRow(ctx, findThreadQuery, threadID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return chat.Thread{}, app.ErrNotFound
+	}
+	if err != nil {
+		return chat.Thread{}, fmt.Errorf("can't find thread %d: %w", threadID, err)
+	}
+	return thread, nil
+}
+
+const (
+	touchThreadQuery = `UPDATE threads SET update_date = $2 WHERE id = $1`
+	addMessageQuery  = `INSERT INTO messages (thread_id, sender_id, text, creation_date)
+VALUES ($1, $2, $3, $4)
+RETURNING ` + messageColumns
+)
+
+func (r *ChatRepo) AddMessage(ctx context.Context, threadID, senderID int64, text string) (chat.Message, error) {
+	var msg chat.Message
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		now := time.Now().UTC()
+		tag, err := tx.Exec(ctx, touchThreadQuery, threadID, now)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+		msg, err = scanMessage(tx.QueryRow(ctx, addMessageQuery, threadID, senderID, text, now))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return chat.Message{}, app.ErrNotFound
+	}
+	if err != nil {
+		return chat.Message{}, fmt.Errorf("can't add message to thread %d: %w", threadID, err)
+	}
+	return msg, nil
+}
+
+const messagesQuery = `SELECT ` + messageColumns + ` FROM messages WHERE thread_id = $1 ORDER BY id`
+
+func (r *ChatRepo) Messages(ctx context.Context, threadID int64) ([]chat.Message, error) {
+	rows, err := r.pool.Query(ctx, messagesQuery, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("can't select messages of thread %d: %w", threadID, err)
+	}
+	return collectMessages(rows)
+}
+
+const markReadQuery = `UPDATE messages SET read_at = $4
+WHERE thread_id = $1 AND sender_id <> $2 AND read_at IS NULL AND ($3 = 0 OR id <= $3)
+RETURNING ` + messageColumns
+
+func (r *ChatRepo) MarkRead(ctx context.Context, threadID, readerID, upTo int64, at time.Time) ([]chat.Message, error) {
+	rows, err := r.pool.Query(ctx, markReadQuery, threadID, readerID, upTo, at)
+	if err != nil {
+		return nil, fmt.Errorf("can't mark thread %d read: %w", threadID, err)
+	}
+	return collectMessages(rows)
+}
+
+// Последнее сообщение и число непрочитанных считаются для каждой переписки отдельно;
+// переписки без сообщений не попадают в выборку из-за внутреннего соединения
+const inboxQuery = `SELECT t.id, t.ad_id, t.buyer_id, t.seller_id, t.creation_date, t.update_date,
+    m.id, m.thread_id, m.sender_id, m.text, m.read_at, m.creation_date,
+    (SELECT count(*) FROM messages u WHERE u.thread_id = t.id AND u.sender_id <> $1 AND u.read_at IS NULL)
+FROM threads t
+JOIN LATERAL (
+    SELECT ` + messageColumns + ` FROM messages WHERE thread_id = t.id ORDER BY id DESC LIMIT 1
+) m ON true
+WHERE t.buyer_id = $1 OR t.seller_id = $1
+ORDER BY m.id DESC`
+
+func (r *ChatRepo) Inbox(ctx context.Context, userID int64) ([]chat.Summary, error) {
+	rows, err := r.pool.Query(ctx, inboxQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("can't select threads of user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	res := []chat.Summary{}
+	for rows.Next() {
+		var s chat.Summary
+		m := &s.LastMessage
+		s.Thread, err = scanThread(rows, &m.ID, &m.ThreadID, &m.SenderID, &m.Text, &m.ReadAt, &m.CreationDate, &s.Unread)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan thread: %w", err)
+		}
+		m.CreationDate = m.CreationDate.UTC()
+		if m.ReadAt != nil {
+			readAt := m.ReadAt.UTC()
+			m.ReadAt = &readAt
+		}
+		res = append(res, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read threads: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package pgrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"homework10/internal/app"
+)
+
+type FavoritesRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewFavorites(pool *pgxpool.Pool) app.Favorites {
+	return &FavoritesRepo{pool: pool}
+}
+
+const addFavoriteQuery = `INSERT INTO favorites (user_id, ad_id, creation_date) VALUES ($1, $2, $3)
+ON CONFLICT (user_id, ad_id) DO NOTHING`
+
+func (r *FavoritesRepo) Add(ctx context.Context, userID, adID int64) error {
+	if _, err := r.pool.Exec(ctx, addFavoriteQuery, userID, adID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("can't add ad %d to favorites of user %d: %w", adID, userID, err)
+	}
+	return nil
+}
+
+const removeFavoriteQuery = `DELETE FROM favorites WHERE user_id = $1 AND ad_id = $2`
+
+func (r *FavoritesRepo) Remove(ctx context.Context, userID, adID int64) error {
+	tag, err := r.pool.Exec(ctx, removeFavoriteQuery, userID, adID)
+	if err != nil {
+		return fmt.Errorf("can't remove ad %d from favorites of user %d: %w", adID, userID, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return app.ErrNotFound
+	}
+	return nil
+}
+
+const listFavoritesQuery = `SELECT ad_id FROM favorites WHERE user_id = $1 ORDER BY creation_date DESC, ad_id DESC`
+
+func (r *FavoritesRepo) List(ctx context.Context, userID int64) ([]int64, error) {
+	rows, err := r.pool.Query(ctx, listFavoritesQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("can't select favorites of user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("can't scan favorite: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read favorites: %w", err)
+	}
+	return ids, nil
+}
+
+const removeAdFavoritesQuery = `DELETE FROM favorites WHERE ad_id = $1`
+
+func (r *FavoritesRepo) RemoveAd(ctx context.Context, adID int64) error {
+	if _, err := r.pool.Exec(ctx, removeAdFavoritesQuery, adID); err != nil {
+		return fmt.Errorf("can't remove ad %d from favorites: %w", adID, err)
+	}
+	return nil
+}
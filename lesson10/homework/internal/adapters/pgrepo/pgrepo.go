@@ -0,0 +1,82 @@
+package pgrepo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/tracelog"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"homework10/internal/logging"
+	"homework10/internal/tracing"
+)
+
+// NewPool открывает пул соединений с PostgreSQL и проверяет, что база доступна.
+// Схема должна быть создана заранее миграциями из каталога migrations.
+// Ошибки запросов пишутся в logger вместе с ID запроса из контекста, а на каждый
+// запрос открывается span в трассировке вызова.
+func NewPool(ctx context.Context, dsn string, logger *zap.Logger) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse postgres dsn: %w", err)
+	}
+	cfg.ConnConfig.Tracer = &queryTracer{log: &tracelog.TraceLog{
+		Logger:   queryLogger(logger),
+		LogLevel: tracelog.LogLevelWarn,
+	}}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("can't create postgres pool: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("can't ping postgres: %w", err)
+	}
+
+	return pool, nil
+}
+
+// queryTracer открывает span на каждый запрос и передаёт запрос дальше в журнал.
+type queryTracer struct {
+	log *tracelog.TraceLog
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation := "query"
+	if fields := strings.Fields(data.SQL); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	ctx, _ = tracing.Start(ctx, "postgres "+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperationKey.String(operation),
+		semconv.DBStatementKey.String(data.SQL),
+	))
+	return t.log.TraceQueryStart(ctx, conn, data)
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	t.log.TraceQueryEnd(ctx, conn, data)
+	tracing.End(trace.SpanFromContext(ctx), data.Err)
+}
+
+func queryLogger(logger *zap.Logger) tracelog.LoggerFunc {
+	return func(ctx context.Context, level tracelog.LogLevel, msg string, data map[string]interface{}) {
+		fields := make([]zap.Field, 0, len(data))
+		for k, v := range data {
+			fields = append(fields, zap.Any(k, v))
+		}
+		lvl := zapcore.WarnLevel
+		if level <= tracelog.LogLevelError {
+			lvl = zapcore.ErrorLevel
+		}
+		logging.FromContext(ctx, logger).Log(lvl, "postgres: "+msg, fields...)
+	}
+}
@@ -0,0 +1,451 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+type Repo struct {
+	pool *pgxpool.Pool
+}
+
+func New(pool *pgxpool.Pool) app.Repository {
+	return &Repo{pool: pool}
+}
+
+const adColumns = `id, title, text, author_id, published, status, reject_reason, images,
+    category, tags, price_amount, price_currency, location, version, creation_date, update_date, deleted_at`
+
+// scanAd читает колонки adColumns и, следом за ними, дополнительные колонки в extra.
+func scanAd(row pgx.Row, extra ...any) (ads.Ad, error) {
+	ad := ads.Ad{}
+	dest := []any{&ad.ID, &ad.Title, &ad.Text, &ad.AuthorID, &ad.Published, &ad.Status, &ad.RejectReason, &ad.Images,
+		&ad.Category, &ad.Tags, &ad.Price.Amount, &ad.Price.Currency, &ad.Location, &ad.Version, &ad.CreationDate, &ad.UpdateDate, &ad.DeletedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	ad.CreationDate = ad.CreationDate.UTC()
+	ad.UpdateDate = ad.UpdateDate.UTC()
+	if ad.DeletedAt != nil {
+		deletedAt := ad.DeletedAt.UTC()
+		ad.DeletedAt = &deletedAt
+	}
+	return ad, nil
+}
+
+func collectAds(rows pgx.Rows) ([]ads.Ad, error) {
+	defer rows.Close()
+
+	adss := []ads.Ad{}
+	for rows.Next() {
+		ad, err := scanAd(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan ad: %w", err)
+		}
+		adss = append(adss, ad)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read ads: %w", err)
+	}
+	return adss, nil
+}
+
+const findAdQuery = `SELECT ` + adColumns + ` FROM ads WHERE id = $1 AND deleted_at IS NULL`
+
+func (r *Repo) Find(ctx context.Context, adID int64) (ads.Ad, error) {
+	ad, err := scanAd(r.pool.QueryRow(ctx, findAdQuery, adID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ads.Ad{}, app.ErrNotFound
+	}
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("can't find ad %d: %w", adID, err)
+	}
+	return ad, nil
+}
+
+const addAdQuery = `INSERT INTO ads (title, text, author_id, published, status, creation_date, update_date,
+    category, tags, price_amount, price_currency, location)
+VALUES ($1, $2, $3, false, 'draft', $4, $4, $5, $6, $7, $8, $9)
+RETURNING ` + adColumns
+
+func (r *Repo) Add(ctx context.Context, title string, text string, attrs ads.Attributes, userID int64) (ads.Ad, error) {
+	var ad ads.Ad
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		ad, err = scanAd(tx.QueryRow(ctx, addAdQuery, title, text, userID, time.Now().UTC(),
+			attrs.Category, tags(attrs.Tags), attrs.Price.Amount, attrs.Price.Currency, attrs.Location))
+		if err != nil {
+			return err
+		}
+		return addRevision(ctx, tx, ad)
+	})
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("can't insert ad: %w", err)
+	}
+	return ad, nil
+}
+
+// Строка объявления блокируется до конца транзакции, поэтому mutate видит
+// последнюю версию, а конкурентные изменения применяются по очереди.
+const findAdForUpdateQuery = `SELECT ` + adColumns + ` FROM ads WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+const updateAdQuery = `UPDATE ads
+SET title = $2, text = $3, status = $4, published = $4 = 'published', reject_reason = $5,
+    images = $7, category = $8, tags = $9, price_amount = $10, price_currency = $11, location = $12,
+    version = version + 1, update_date = $6
+WHERE id = $1
+RETURNING ` + adColumns
+
+func (r *Repo) Update(ctx context.Context, adID int64, mutate func(ad *ads.Ad) error) (ads.Ad, error) {
+	var ad ads.Ad
+	var mutateErr error
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		cur, err := scanAd(tx.QueryRow(ctx, findAdForUpdateQuery, adID))
+		if err != nil {
+			return err
+		}
+		old := cur
+		if mutateErr = mutate(&cur); mutateErr != nil {
+			return mutateErr
+		}
+		// nil-срез pgx записал бы как NULL
+		if cur.Images == nil {
+			cur.Images = []ads.Image{}
+		}
+		ad, err = scanAd(tx.QueryRow(ctx, updateAdQuery, adID, cur.Title, cur.Text, string(cur.Status), cur.RejectReason, time.Now().UTC(), cur.Images,
+			cur.Category, tags(cur.Tags), cur.Price.Amount, cur.Price.Currency, cur.Location))
+		if err != nil || (ad.Title == old.Title && ad.Text == old.Text) {
+			return err
+		}
+		return addRevision(ctx, tx, ad)
+	})
+	if mutateErr != nil {
+		return ads.Ad{}, mutateErr
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ads.Ad{}, app.ErrNotFound
+	}
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("can't update ad %d: %w", adID, err)
+	}
+	return ad, nil
+}
+
+// tags: nil-срез pgx записал бы как NULL.
+func tags(t []string) []string {
+	if t == nil {
+		return []string{}
+	}
+	return t
+}
+
+// query собирает SELECT с условиями через AND и нумерацией аргументов.
+type query struct {
+	conds []string
+	args  []any
+}
+
+func (q *query) arg(v any) string {
+	q.args = append(q.args, v)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *query) where(cond string) {
+	q.conds = append(q.conds, cond)
+}
+
+// sortColumn совпадает с порядком app.Page.Less: заголовки сравниваются побайтово, как строки в Go.
+func sortColumn(sortBy app.SortKey) string {
+	switch sortBy {
+	case app.SortByCreationDate:
+		return "creation_date"
+	case app.SortByUpdateDate:
+		return "update_date"
+	case app.SortByTitle:
+		return `title COLLATE "C"`
+	}
+	return ""
+}
+
+// selectPage дописывает к запросу условие курсора, сортировку и LIMIT страницы.
+func (q *query) selectPage(page app.Page) string {
+	dir, op := "ASC", ">"
+	if page.Desc {
+		dir, op = "DESC", "<"
+	}
+
+	col := sortColumn(page.SortBy)
+	if page.After != nil {
+		var value any
+		switch page.SortBy {
+		case app.SortByCreationDate, app.SortByUpdateDate:
+			value = page.After.Time
+		case app.SortByTitle:
+			value = page.After.Title
+		}
+		if col == "" {
+			q.where("id " + op + " " + q.arg(page.After.ID))
+		} else {
+			q.where("(" + col + ", id) " + op + " (" + q.arg(value) + ", " + q.arg(page.After.ID) + ")")
+		}
+	}
+
+	order := "id " + dir
+	if col != "" {
+		order = col + " " + dir + ", " + order
+	}
+
+	return `SELECT ` + adColumns + ` FROM ads WHERE ` + q.condition() + ` ORDER BY ` + order + ` LIMIT ` + q.arg(page.Limit+1)
+}
+
+func (q *query) condition() string {
+	if len(q.conds) == 0 {
+		return "true"
+	}
+	return strings.Join(q.conds, " AND ")
+}
+
+// filter переводит app.Filter в условия WHERE с той же семантикой, что и Filter.Match.
+func (q *query) filter(filter app.Filter) {
+	if filter.Deleted {
+		q.where("deleted_at IS NOT NULL")
+	} else {
+		q.where("deleted_at IS NULL")
+	}
+	switch filter.Published {
+	case app.OnlyPublished:
+		q.where("published")
+	case app.OnlyUnpublished:
+		q.where("NOT published")
+	}
+	if filter.Status != "" {
+		q.where("status = " + q.arg(string(filter.Status)))
+	}
+	if filter.AuthorID != nil {
+		q.where("author_id = " + q.arg(*filter.AuthorID))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		q.where("creation_date >= " + q.arg(filter.CreatedFrom))
+	}
+	if !filter.CreatedTo.IsZero() {
+		q.where("creation_date < " + q.arg(filter.CreatedTo))
+	}
+	if !filter.UpdatedFrom.IsZero() {
+		q.where("update_date >= " + q.arg(filter.UpdatedFrom))
+	}
+	if !filter.UpdatedTo.IsZero() {
+		q.where("update_date < " + q.arg(filter.UpdatedTo))
+	}
+	if filter.TitleContains != "" {
+		q.where("strpos(lower(title), lower(" + q.arg(filter.TitleContains) + ")) > 0")
+	}
+	if filter.TextContains != "" {
+		q.where("strpos(lower(text), lower(" + q.arg(filter.TextContains) + ")) > 0")
+	}
+	if filter.Category != "" {
+		// ID категорий не содержат символов шаблона LIKE
+		q.where("(category = " + q.arg(filter.Category) + " OR category LIKE " + q.arg(filter.Category+"/%") + ")")
+	}
+	if len(filter.Tags) > 0 {
+		q.where("tags @> " + q.arg(filter.Tags))
+	}
+	if filter.Currency != "" {
+		q.where("price_currency = " + q.arg(filter.Currency))
+	}
+	if filter.PriceMin != 0 {
+		q.where("price_amount >= " + q.arg(filter.PriceMin))
+	}
+	if filter.PriceMax != 0 {
+		q.where("price_amount BETWEEN 1 AND " + q.arg(filter.PriceMax))
+	}
+	if filter.Location != "" {
+		q.where("lower(location) = lower(" + q.arg(filter.Location) + ")")
+	}
+	if len(app.Tokenize(filter.Keywords)) > 0 {
+		q.where(searchVector + " @@ plainto_tsquery('simple', " + q.arg(filter.Keywords) + ")")
+	}
+}
+
+func (r *Repo) GetAdsByFilter(ctx context.Context, filter app.Filter, page app.Page) ([]ads.Ad, error) {
+	q := &query{}
+	q.filter(filter)
+	rows, err := r.pool.Query(ctx, q.selectPage(page), q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't select ads by filter: %w", err)
+	}
+	return collectAds(rows)
+}
+
+func (r *Repo) GetByTitle(ctx context.Context, title string, page app.Page) ([]ads.Ad, error) {
+	q := &query{}
+	q.where("title = " + q.arg(title))
+	q.where("deleted_at IS NULL")
+	rows, err := r.pool.Query(ctx, q.selectPage(page), q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't select ads by title: %w", err)
+	}
+	return collectAds(rows)
+}
+
+// searchVector должен совпадать с выражением индекса ads_search_idx, иначе индекс не используется.
+// Вес A у заголовка соответствует app.TitleWeight.
+const searchVector = `(setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', text), 'B'))`
+
+func (r *Repo) Search(ctx context.Context, search string, filter app.Filter, limit int) ([]app.SearchResult, error) {
+	q := &query{}
+	q.filter(filter)
+	tsQuery := "plainto_tsquery('simple', " + q.arg(search) + ")"
+	q.where(searchVector + " @@ " + tsQuery)
+	sql := `SELECT ` + adColumns + `, ts_rank(` + searchVector + `, ` + tsQuery + `) AS score FROM ads WHERE ` +
+		q.condition() + ` ORDER BY score DESC, id LIMIT ` + q.arg(limit)
+
+	rows, err := r.pool.Query(ctx, sql, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't search ads: %w", err)
+	}
+	defer rows.Close()
+
+	results := []app.SearchResult{}
+	for rows.Next() {
+		var score float32
+		ad, err := scanAd(rows, &score)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan ad: %w", err)
+		}
+		results = append(results, app.SearchResult{Ad: ad, Score: float64(score)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read ads: %w", err)
+	}
+	return results, nil
+}
+
+const deleteAdQuery = `UPDATE ads SET deleted_at = $2 WHERE id = $1 RETURNING ` + adColumns
+
+func (r *Repo) Delete(ctx context.Context, adID int64, check func(ad ads.Ad) error) (ads.Ad, error) {
+	var ad ads.Ad
+	var checkErr error
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		ad, err = scanAd(tx.QueryRow(ctx, findAdForUpdateQuery, adID))
+		if err != nil {
+			return err
+		}
+		if checkErr = check(ad); checkErr != nil {
+			return checkErr
+		}
+		ad, err = scanAd(tx.QueryRow(ctx, deleteAdQuery, adID, time.Now().UTC()))
+		return err
+	})
+	if checkErr != nil {
+		return ads.Ad{}, checkErr
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ads.Ad{}, app.ErrNotFound
+	}
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("can't delete ad %d: %w", adID, err)
+	}
+	return ad, nil
+}
+
+const (
+	findDeletedAdForUpdateQuery = `SELECT ` + adColumns + ` FROM ads WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`
+	restoreAdQuery              = `UPDATE ads SET deleted_at = NULL WHERE id = $1 RETURNING ` + adColumns
+)
+
+func (r *Repo) Restore(ctx context.Context, adID int64, check func(ad ads.Ad) error) (ads.Ad, error) {
+	var ad ads.Ad
+	var checkErr error
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		cur, err := scanAd(tx.QueryRow(ctx, findDeletedAdForUpdateQuery, adID))
+		if err != nil {
+			return err
+		}
+		if checkErr = check(cur); checkErr != nil {
+			return checkErr
+		}
+		ad, err = scanAd(tx.QueryRow(ctx, restoreAdQuery, adID))
+		return err
+	})
+	if checkErr != nil {
+		return ads.Ad{}, checkErr
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ads.Ad{}, app.ErrNotFound
+	}
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("can't restore ad %d: %w", adID, err)
+	}
+	return ad, nil
+}
+
+// UPDATE ... RETURNING не гарантирует порядок строк, поэтому он задаётся снаружи.
+const (
+	deleteAdsByAuthorQuery = `WITH changed AS (
+    UPDATE ads SET deleted_at = $2 WHERE author_id = $1 AND deleted_at IS NULL RETURNING ` + adColumns + `
+) SELECT ` + adColumns + ` FROM changed ORDER BY id`
+	restoreAdsByAuthorQuery = `WITH changed AS (
+    UPDATE ads SET deleted_at = NULL WHERE author_id = $1 AND deleted_at = $2 RETURNING ` + adColumns + `
+) SELECT ` + adColumns + ` FROM changed ORDER BY id`
+)
+
+func (r *Repo) DeleteByAuthor(ctx context.Context, authorID int64, at time.Time) ([]ads.Ad, error) {
+	rows, err := r.pool.Query(ctx, deleteAdsByAuthorQuery, authorID, at)
+	if err != nil {
+		return nil, fmt.Errorf("can't delete ads of user %d: %w", authorID, err)
+	}
+	return collectAds(rows)
+}
+
+func (r *Repo) RestoreByAuthor(ctx context.Context, authorID int64, at time.Time) ([]ads.Ad, error) {
+	rows, err := r.pool.Query(ctx, restoreAdsByAuthorQuery, authorID, at)
+	if err != nil {
+		return nil, fmt.Errorf("can't restore ads of user %d: %w", authorID, err)
+	}
+	return collectAds(rows)
+}
+
+// Версии объявлений удаляются каскадно внешним ключом ad_revisions.
+const purgeAdsQuery = `DELETE FROM ads WHERE deleted_at < $1 RETURNING ` + adColumns
+
+func (r *Repo) Purge(ctx context.Context, before time.Time) ([]ads.Ad, error) {
+	rows, err := r.pool.Query(ctx, purgeAdsQuery, before)
+	if err != nil {
+		return nil, fmt.Errorf("can't purge ads: %w", err)
+	}
+	return collectAds(rows)
+}
+
+const countAdsQuery = `SELECT status, count(*) FROM ads WHERE deleted_at IS NULL GROUP BY status`
+
+func (r *Repo) CountByStatus(ctx context.Context) (map[ads.Status]int64, error) {
+	rows, err := r.pool.Query(ctx, countAdsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't count ads: %w", err)
+	}
+	defer rows.Close()
+	counts := map[ads.Status]int64{}
+	for rows.Next() {
+		var status ads.Status
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("can't count ads: %w", err)
+		}
+		counts[status] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't count ads: %w", err)
+	}
+	return counts, nil
+}
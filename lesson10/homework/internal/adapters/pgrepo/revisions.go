@@ -0,0 +1,71 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+const revisionColumns = `ad_id, number, title, text, editor_id, creation_date`
+
+func scanRevision(row pgx.Row) (ads.Revision, error) {
+	var rev ads.Revision
+	err := row.Scan(&rev.AdID, &rev.Number, &rev.Title, &rev.Text, &rev.EditorID, &rev.CreationDate)
+	return rev, err
+}
+
+const addRevisionQuery = `INSERT INTO ad_revisions (` + revisionColumns + `)
+SELECT $1, coalesce(max(number), 0) + 1, $2, $3, $4, $5 FROM ad_revisions WHERE ad_id = $1
+RETURNING ` + revisionColumns
+
+// addRevision сохраняет содержимое объявления как его следующую версию в транзакции,
+// добавившей или изменившей объявление. Строка объявления в ней уже заблокирована,
+// поэтому конкурентные правки получают номера в том порядке, в каком применены.
+func addRevision(ctx context.Context, tx pgx.Tx, ad ads.Ad) error {
+	_, err := tx.Exec(ctx, addRevisionQuery, ad.ID, ad.Title, ad.Text, ad.AuthorID, ad.UpdateDate)
+	if err != nil {
+		return fmt.Errorf("can't add revision of ad %d: %w", ad.ID, err)
+	}
+	return nil
+}
+
+const revisionsQuery = `SELECT ` + revisionColumns + ` FROM ad_revisions WHERE ad_id = $1 ORDER BY number`
+
+func (r *Repo) Revisions(ctx context.Context, adID int64) ([]ads.Revision, error) {
+	rows, err := r.pool.Query(ctx, revisionsQuery, adID)
+	if err != nil {
+		return nil, fmt.Errorf("can't select revisions of ad %d: %w", adID, err)
+	}
+	defer rows.Close()
+
+	revs := []ads.Revision{}
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan revision: %w", err)
+		}
+		revs = append(revs, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read revisions: %w", err)
+	}
+	return revs, nil
+}
+
+const revisionQuery = `SELECT ` + revisionColumns + ` FROM ad_revisions WHERE ad_id = $1 AND number = $2`
+
+func (r *Repo) Revision(ctx context.Context, adID int64, number int64) (ads.Revision, error) {
+	rev, err := scanRevision(r.pool.QueryRow(ctx, revisionQuery, adID, number))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ads.Revision{}, app.ErrNotFound
+	}
+	if err != nil {
+		return ads.Revision{}, fmt.Errorf("can't find revision %d of ad %d: %w", number, adID, err)
+	}
+	return rev, nil
+}
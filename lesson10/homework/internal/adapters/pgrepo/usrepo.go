@@ -0,0 +1,189 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"homework10/internal/app"
+	"homework10/internal/user"
+)
+
+type UserRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewUsers(pool *pgxpool.Pool) app.Users {
+	return &UserRepo{pool: pool}
+}
+
+const userColumns = `id, nickname, email, display_name, avatar_url, registration_date, rating_score, rating_votes, deleted_at`
+
+func scanUser(row pgx.Row) (user.User, error) {
+	us := user.User{}
+	err := row.Scan(&us.ID, &us.Nickname, &us.Email, &us.DisplayName, &us.AvatarURL, &us.RegistrationDate,
+		&us.Rating.Score, &us.Rating.Votes, &us.DeletedAt)
+	if err != nil {
+		return user.User{}, err
+	}
+	us.RegistrationDate = us.RegistrationDate.UTC()
+	if us.DeletedAt != nil {
+		deletedAt := us.DeletedAt.UTC()
+		us.DeletedAt = &deletedAt
+	}
+	return us, nil
+}
+
+const getUserQuery = `SELECT ` + userColumns + ` FROM users WHERE id = $1 AND deleted_at IS NULL`
+
+func (u *UserRepo) Get(ctx context.Context, userID int64) (user.User, error) {
+	us, err := scanUser(u.pool.QueryRow(ctx, getUserQuery, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return user.User{}, app.ErrNotFound
+	}
+	if err != nil {
+		return user.User{}, fmt.Errorf("can't get user %d: %w", userID, err)
+	}
+	return us, nil
+}
+
+// uniqueViolation - код ошибки PostgreSQL при нарушении уникального индекса.
+const uniqueViolation = "23505"
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+const (
+	// Конфликт по любому уникальному индексу: ID, никнейму или email
+	createUserQuery = `INSERT INTO users (id, nickname, email, password_hash, registration_date)
+VALUES (COALESCE(NULLIF($1::bigint, 0), nextval('users_id_seq')), $2, $3, $4, $5)
+ON CONFLICT DO NOTHING
+RETURNING ` + userColumns
+	// Заданный при импорте ID не должен потом выдаться новому пользователю
+	advanceUserIDQuery = `SELECT setval('users_id_seq', $1) WHERE $1 >= (SELECT last_value FROM users_id_seq)`
+)
+
+// Удалённый пользователь занимает свой ID, никнейм и email до очистки.
+func (u *UserRepo) Create(ctx context.Context, nickname, email, passwordHash string, userID int64) (user.User, error) {
+	var us user.User
+	err := pgx.BeginFunc(ctx, u.pool, func(tx pgx.Tx) error {
+		var err error
+		us, err = scanUser(tx.QueryRow(ctx, createUserQuery, userID, nickname, email, passwordHash, time.Now().UTC()))
+		if err != nil || userID == 0 {
+			return err
+		}
+		_, err = tx.Exec(ctx, advanceUserIDQuery, userID)
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return user.User{}, app.ErrAlreadyExists
+	}
+	if err != nil {
+		return user.User{}, fmt.Errorf("can't insert user: %w", err)
+	}
+	return us, nil
+}
+
+const changeUserInfoQuery = `UPDATE users SET nickname = $2, email = $3, display_name = $4, avatar_url = $5
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING ` + userColumns
+
+func (u *UserRepo) ChangeInfo(ctx context.Context, userID int64, nickname, email string, profile user.Profile) (user.User, error) {
+	us, err := scanUser(u.pool.QueryRow(ctx, changeUserInfoQuery, userID, nickname, email, profile.DisplayName, profile.AvatarURL))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return user.User{}, app.ErrNotFound
+	}
+	if isUniqueViolation(err) {
+		return user.User{}, app.ErrAlreadyExists
+	}
+	if err != nil {
+		return user.User{}, fmt.Errorf("can't update user %d: %w", userID, err)
+	}
+	return us, nil
+}
+
+const deleteUserQuery = `UPDATE users SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL
+RETURNING ` + userColumns
+
+func (u *UserRepo) DeleteByID(ctx context.Context, userID int64) (user.User, error) {
+	us, err := scanUser(u.pool.QueryRow(ctx, deleteUserQuery, userID, time.Now().UTC()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return user.User{}, app.ErrNotFound
+	}
+	if err != nil {
+		return user.User{}, fmt.Errorf("can't delete user %d: %w", userID, err)
+	}
+	return us, nil
+}
+
+const (
+	findDeletedUserForUpdateQuery = `SELECT ` + userColumns + ` FROM users WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`
+	restoreUserQuery              = `UPDATE users SET deleted_at = NULL WHERE id = $1 RETURNING ` + userColumns
+)
+
+func (u *UserRepo) Restore(ctx context.Context, userID int64, check func(u user.User) error) (user.User, error) {
+	var us user.User
+	var checkErr error
+	err := pgx.BeginFunc(ctx, u.pool, func(tx pgx.Tx) error {
+		cur, err := scanUser(tx.QueryRow(ctx, findDeletedUserForUpdateQuery, userID))
+		if err != nil {
+			return err
+		}
+		if checkErr = check(cur); checkErr != nil {
+			return checkErr
+		}
+		us, err = scanUser(tx.QueryRow(ctx, restoreUserQuery, userID))
+		return err
+	})
+	if checkErr != nil {
+		return user.User{}, checkErr
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return user.User{}, app.ErrNotFound
+	}
+	if err != nil {
+		return user.User{}, fmt.Errorf("can't restore user %d: %w", userID, err)
+	}
+	return us, nil
+}
+
+const purgeUsersQuery = `DELETE FROM users WHERE deleted_at < $1`
+
+func (u *UserRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := u.pool.Exec(ctx, purgeUsersQuery, before)
+	if err != nil {
+		return 0, fmt.Errorf("can't purge users: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+const passwordHashQuery = `SELECT password_hash FROM users WHERE id = $1 AND deleted_at IS NULL`
+
+func (u *UserRepo) PasswordHash(ctx context.Context, userID int64) (string, error) {
+	var hash string
+	err := u.pool.QueryRow(ctx, passwordHashQuery, userID).Scan(&hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", app.ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("can't get password hash of user %d: %w", userID, err)
+	}
+	return hash, nil
+}
+
+const countUsersQuery = `SELECT count(*) FROM users WHERE deleted_at IS NULL`
+
+func (u *UserRepo) Count(ctx context.Context) (int64, error) {
+	var n int64
+	if err := u.pool.QueryRow(ctx, countUsersQuery).Scan(&n); err != nil {
+		return 0, fmt.Errorf("can't count users: %w", err)
+	}
+	return n, nil
+}
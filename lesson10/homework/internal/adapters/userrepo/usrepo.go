@@ -2,7 +2,9 @@ package userrepo
 
 import (
 	"context"
+	"strings"
 	"sync"
+	"time"
 
 	"homework10/internal/app"
 	"homework10/internal/user"
@@ -11,6 +13,8 @@ import (
 type UserRepo struct {
 	mx *sync.RWMutex
 	mp map[int64]user.User
+	pw map[int64]string
+	// ID - наибольший выданный или занятый ID
 	ID int64
 }
 
@@ -18,43 +22,142 @@ func New() app.Users {
 	return &UserRepo{
 		mx: &sync.RWMutex{},
 		mp: map[int64]user.User{},
+		pw: map[int64]string{},
 	}
 }
 
-func (u *UserRepo) Find(ctx context.Context, userID int64) (int64, bool) {
+func (u *UserRepo) Get(ctx context.Context, userID int64) (user.User, error) {
 	u.mx.Lock()
 	defer u.mx.Unlock()
-	if _, ok := u.mp[userID]; !ok {
-		return -1, false
+	return u.get(userID)
+}
+
+// get возвращает неудалённого пользователя; вызывается под u.mx.
+func (u *UserRepo) get(userID int64) (user.User, error) {
+	us, ok := u.mp[userID]
+	if !ok || us.DeletedAt != nil {
+		return user.User{}, app.ErrNotFound
 	}
-	return userID, true
+	return us, nil
 }
 
-func (u *UserRepo) ChangeInfo(ctx context.Context, userID int64, nickname, email string) user.User {
+func (u *UserRepo) ChangeInfo(ctx context.Context, userID int64, nickname, email string, profile user.Profile) (user.User, error) {
 	u.mx.Lock()
 	defer u.mx.Unlock()
-	us := u.mp[userID]
+	us, err := u.get(userID)
+	if err != nil {
+		return user.User{}, err
+	}
+	if u.taken(userID, nickname, email) {
+		return user.User{}, app.ErrAlreadyExists
+	}
 	us.Nickname = nickname
 	us.Email = email
+	us.Profile = profile
 	u.mp[userID] = us
-	return u.mp[userID]
+	return u.mp[userID], nil
 }
 
-func (u *UserRepo) Create(ctx context.Context, nickname string, email string, userID int64) user.User {
+func (u *UserRepo) Create(ctx context.Context, nickname, email, passwordHash string, userID int64) (user.User, error) {
 	u.mx.Lock()
 	defer u.mx.Unlock()
+	if _, ok := u.mp[userID]; ok {
+		return user.User{}, app.ErrAlreadyExists
+	}
+	if u.taken(userID, nickname, email) {
+		return user.User{}, app.ErrAlreadyExists
+	}
+	if userID == 0 {
+		userID = u.ID + 1
+	}
+	if userID > u.ID {
+		u.ID = userID
+	}
+	u.pw[userID] = passwordHash
 	u.mp[userID] = user.User{
-		ID:       userID,
-		Nickname: nickname,
-		Email:    email,
+		ID:               userID,
+		Nickname:         nickname,
+		Email:            email,
+		RegistrationDate: time.Now().UTC(),
 	}
-	return u.mp[userID]
+	return u.mp[userID], nil
+}
+
+// taken проверяет, занят ли никнейм или email другим пользователем, в том числе
+// удалённым; вызывается под u.mx.
+func (u *UserRepo) taken(userID int64, nickname, email string) bool {
+	for id, us := range u.mp {
+		if id != userID && (strings.EqualFold(us.Nickname, nickname) || strings.EqualFold(us.Email, email)) {
+			return true
+		}
+	}
+	return false
 }
 
 func (u *UserRepo) DeleteByID(ctx context.Context, userID int64) (user.User, error) {
 	u.mx.Lock()
 	defer u.mx.Unlock()
-	res := u.mp[userID]
-	delete(u.mp, userID)
+	res, err := u.get(userID)
+	if err != nil {
+		return user.User{}, err
+	}
+	now := time.Now().UTC()
+	res.DeletedAt = &now
+	u.mp[userID] = res
 	return res, nil
 }
+
+func (u *UserRepo) Restore(ctx context.Context, userID int64, check func(u user.User) error) (user.User, error) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	us, ok := u.mp[userID]
+	if !ok || us.DeletedAt == nil {
+		return user.User{}, app.ErrNotFound
+	}
+	if err := check(us); err != nil {
+		return user.User{}, err
+	}
+	us.DeletedAt = nil
+	u.mp[userID] = us
+	return us, nil
+}
+
+func (u *UserRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	var n int64
+	for id, us := range u.mp {
+		if us.DeletedAt == nil || !us.DeletedAt.Before(before) {
+			continue
+		}
+		delete(u.mp, id)
+		delete(u.pw, id)
+		n++
+	}
+	return n, nil
+}
+
+func (u *UserRepo) PasswordHash(ctx context.Context, userID int64) (string, error) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	if _, err := u.get(userID); err != nil {
+		return "", err
+	}
+	hash, ok := u.pw[userID]
+	if !ok {
+		return "", app.ErrNotFound
+	}
+	return hash, nil
+}
+
+func (u *UserRepo) Count(ctx context.Context) (int64, error) {
+	u.mx.RLock()
+	defer u.mx.RUnlock()
+	var n int64
+	for _, us := range u.mp {
+		if us.DeletedAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
@@ -2,12 +2,43 @@ package ads
 
 import "time"
 
+// Status - состояние объявления в процессе модерации.
+type Status string
+
+const (
+	StatusDraft     Status = "draft"
+	StatusPending   Status = "pending"
+	StatusPublished Status = "published"
+	StatusRejected  Status = "rejected"
+	StatusArchived  Status = "archived"
+)
+
+// Statuses перечисляет все состояния объявления в порядке процесса модерации.
+var Statuses = []Status{StatusDraft, StatusPending, StatusPublished, StatusRejected, StatusArchived}
+
+func (s Status) Valid() bool {
+	switch s {
+	case StatusDraft, StatusPending, StatusPublished, StatusRejected, StatusArchived:
+		return true
+	}
+	return false
+}
+
 type Ad struct {
-	ID           int64
-	Title        string `validate:"range:1,99"`
-	Text         string `validate:"range:1,499"`
-	AuthorID     int64
+	ID       int64
+	Title    string `validate:"range:1,99"`
+	Text     string `validate:"range:1,499"`
+	AuthorID int64
+	Attributes
+	// Published совпадает с Status == StatusPublished, по нему работают фильтры.
 	Published    bool
+	Status       Status
+	RejectReason string
+	Images       []Image
+	// Version начинается с 1 и увеличивается при каждом изменении объявления
+	Version      int64
 	CreationDate time.Time
 	UpdateDate   time.Time
+	// DeletedAt задан у удалённого объявления: оно хранится до окончательной очистки
+	DeletedAt *time.Time
 }
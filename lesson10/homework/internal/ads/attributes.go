@@ -0,0 +1,87 @@
+package ads
+
+import "strings"
+
+// Attributes - необязательные атрибуты объявления, по которым его находят в выдаче.
+type Attributes struct {
+	// Category - ID категории из дерева Categories, пусто - без категории
+	Category string   `validate:"range:0,63"`
+	Tags     []string `validate:"range:1,30"`
+	Price    Price
+	Location string `validate:"range:0,99"`
+}
+
+type Price struct {
+	// Amount - цена в минимальных единицах валюты (копейках, центах); 0 - цена не указана
+	Amount   int64
+	Currency string
+}
+
+// Currencies - валюты, в которых можно указать цену.
+var Currencies = map[string]bool{
+	"RUB": true,
+	"USD": true,
+	"EUR": true,
+}
+
+// Category - узел дерева категорий. ID вложенной категории начинается с ID
+// родительской и "/", например "transport/cars".
+type Category struct {
+	ID       string
+	Name     string
+	Children []Category
+}
+
+var categories = []Category{
+	{ID: "transport", Name: "Transport", Children: []Category{
+		{ID: "transport/cars", Name: "Cars"},
+		{ID: "transport/motorcycles", Name: "Motorcycles"},
+		{ID: "transport/bicycles", Name: "Bicycles"},
+		{ID: "transport/parts", Name: "Parts and accessories"},
+	}},
+	{ID: "realty", Name: "Real estate", Children: []Category{
+		{ID: "realty/apartments", Name: "Apartments"},
+		{ID: "realty/houses", Name: "Houses"},
+		{ID: "realty/rooms", Name: "Rooms"},
+	}},
+	{ID: "electronics", Name: "Electronics", Children: []Category{
+		{ID: "electronics/phones", Name: "Phones"},
+		{ID: "electronics/computers", Name: "Computers"},
+		{ID: "electronics/audio", Name: "Audio and video"},
+	}},
+	{ID: "home", Name: "Home and garden", Children: []Category{
+		{ID: "home/furniture", Name: "Furniture"},
+		{ID: "home/appliances", Name: "Appliances"},
+	}},
+	{ID: "clothing", Name: "Clothing and shoes"},
+	{ID: "hobby", Name: "Hobby and leisure"},
+	{ID: "services", Name: "Services"},
+	{ID: "jobs", Name: "Jobs"},
+}
+
+var categoryIDs = map[string]bool{}
+
+func init() {
+	var walk func([]Category)
+	walk = func(cs []Category) {
+		for _, c := range cs {
+			categoryIDs[c.ID] = true
+			walk(c.Children)
+		}
+	}
+	walk(categories)
+}
+
+// Categories возвращает дерево категорий.
+func Categories() []Category {
+	return categories
+}
+
+func KnownCategory(id string) bool {
+	return categoryIDs[id]
+}
+
+// InCategory сообщает, относится ли category к категории root или к одной из вложенных в неё.
+func InCategory(category, root string) bool {
+	return category == root || strings.HasPrefix(category, root+"/")
+}
@@ -0,0 +1,16 @@
+package ads
+
+import "time"
+
+// Image - изображение объявления. Содержимое и миниатюра лежат в хранилище файлов
+// под ключами Key и ThumbnailKey, миниатюра всегда в формате JPEG.
+type Image struct {
+	ID           string
+	ContentType  string
+	Size         int64
+	Width        int
+	Height       int
+	Key          string
+	ThumbnailKey string
+	CreationDate time.Time
+}
@@ -0,0 +1,14 @@
+package ads
+
+import "time"
+
+// Revision - неизменяемая версия содержимого объявления. Версии нумеруются с 1 отдельно
+// для каждого объявления, первая - содержимое при создании.
+type Revision struct {
+	AdID         int64
+	Number       int64
+	Title        string
+	Text         string
+	EditorID     int64
+	CreationDate time.Time
+}
@@ -3,191 +3,512 @@ package app
 import (
 	"context"
 	"errors"
+	"io"
+	"time"
 
-	"github.com/gzesv/validatorn"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 
 	"homework10/internal/ads"
+	"homework10/internal/chat"
 	"homework10/internal/user"
 )
 
 type App interface {
-	CreateAd(ctx context.Context, title string, text string, userID int64) (ads.Ad, error)
-	ChangeAdStatus(ctx context.Context, adID int64, UserID int64, published bool) (ads.Ad, error)
-	UpdateAd(ctx context.Context, adID int64, UserID int64, title string, text string) (ads.Ad, error)
-	GetAdsByTitle(ctx context.Context, title string) ([]ads.Ad, error)
-	GetAllAdsByFilter(ctx context.Context, filter Filter) ([]ads.Ad, error)
-	ChangeUserInfo(ctx context.Context, userID int64, nickname, email string) (user.User, error)
-	CreateUser(ctx context.Context, nickname, email string, userID int64) (user.User, error)
-	NewFilter(ctx context.Context) (Filter, error)
-	FindUser(ctx context.Context, userID int64) (int64, bool)
-	DeleteAd(ctx context.Context, adID, userID int64) (ads.Ad, error)
+	// CreateAd и UpdateAd приводят метки к нижнему регистру без повторов; цена без суммы
+	// означает, что цена не указана, а с суммой требует валюту из ads.Currencies.
+	CreateAd(ctx context.Context, title string, text string, attrs ads.Attributes) (ads.Ad, error)
+	// ChangeAdStatus переводит объявление в состояние status по правилам модерации;
+	// reason обязателен при отклонении. Недопустимый переход - ErrInvalidTransition.
+	ChangeAdStatus(ctx context.Context, adID int64, status ads.Status, reason string, version int64) (ads.Ad, error)
+	// UpdateAd меняет заголовок и текст объявления, а с непустым attrs - и его атрибуты.
+	// Опубликованное объявление, как и после AddAdImage, снимается с публикации до проверки.
+	// Здесь и в остальных методах, изменяющих объявление: если version не AnyVersion,
+	// а объявление с тех пор изменилось, возвращается ErrVersionConflict.
+	UpdateAd(ctx context.Context, adID int64, title string, text string, attrs *ads.Attributes, version int64) (ads.Ad, error)
+	GetAd(ctx context.Context, adID int64) (ads.Ad, error)
+	// ListAdRevisions возвращает все версии содержимого объявления, начиная с первой.
+	ListAdRevisions(ctx context.Context, adID int64) ([]ads.Revision, error)
+	// AddAdImage прикрепляет к объявлению изображение JPEG, PNG или GIF не больше
+	// MaxImageSize; AdImage отдаёт его содержимое или миниатюру и тип содержимого.
+	AddAdImage(ctx context.Context, adID int64, r io.Reader) (ads.Ad, error)
+	AdImage(ctx context.Context, adID int64, imageID string, thumbnail bool) (io.ReadCloser, string, error)
+	// RestoreAdRevision делает текущим содержимое версии number, записывая его как новую версию.
+	RestoreAdRevision(ctx context.Context, adID int64, number int64) (ads.Ad, error)
+	GetAdsByTitle(ctx context.Context, title string, p Pagination) (AdsPage, error)
+	GetAllAdsByFilter(ctx context.Context, filter Filter, p Pagination) (AdsPage, error)
+	SearchAds(ctx context.Context, query string, limit int) ([]SearchResult, error)
+	// ChangeUserInfo меняет никнейм и email, а с profile != nil - и профиль пользователя целиком.
+	ChangeUserInfo(ctx context.Context, userID int64, nickname, email string, profile *user.Profile) (user.User, error)
+	// CreateUser регистрирует пользователя; при userID == 0 ID выделяет хранилище. Задать
+	// ID самому может только администратор или любой клиент в режиме импорта (WithUserImport).
+	// Занятые никнейм или email (без учёта регистра) - ErrAlreadyExists.
+	CreateUser(ctx context.Context, nickname, email, password string, userID int64) (user.User, error)
+	// Authenticate проверяет пароль пользователя; при неверной паре возвращает ErrUnauthenticated.
+	Authenticate(ctx context.Context, userID int64, password string) (user.User, error)
+	FindUser(ctx context.Context, userID int64) (user.User, bool)
+	// GetUser возвращает профиль пользователя. Email видят только сам пользователь и
+	// администраторы: остальным клиентам, в том числе анонимным, он приходит пустым.
+	GetUser(ctx context.Context, userID int64) (user.User, error)
+	// ListUserAds возвращает опубликованные объявления пользователя: их видит любой клиент.
+	ListUserAds(ctx context.Context, userID int64, p Pagination) (AdsPage, error)
+	// DeleteAd и DeleteUser помечают запись удалённой: она пропадает из выдачи, но до очистки
+	// (PurgeDeleted) её может восстановить администратор. Вместе с пользователем удаляются
+	// все его объявления.
+	DeleteAd(ctx context.Context, adID int64, version int64) (ads.Ad, error)
 	DeleteUser(ctx context.Context, userID int64) (user.User, error)
+	// RestoreAd, RestoreUser и ListDeletedAds доступны только администраторам. Объявление
+	// удалённого автора не восстанавливается (ErrAuthorDeleted); с пользователем
+	// восстанавливаются объявления, удалённые вместе с ним.
+	RestoreAd(ctx context.Context, adID int64) (ads.Ad, error)
+	RestoreUser(ctx context.Context, userID int64) (user.User, error)
+	ListDeletedAds(ctx context.Context, p Pagination) (AdsPage, error)
+	// PurgeDeleted окончательно удаляет объявления и пользователей, удалённых раньше before.
+	PurgeDeleted(ctx context.Context, before time.Time) (Purged, error)
+	// WatchAds подписывает на события объявлений, подходящих под filter, до отмены ctx.
+	WatchAds(ctx context.Context, filter Filter) *Subscription
+	// AddFavorite, RemoveFavorite и ListFavorites доступны только самому пользователю userID.
+	// Удалённое объявление пропадает из избранного всех пользователей.
+	AddFavorite(ctx context.Context, userID, adID int64) (ads.Ad, error)
+	RemoveFavorite(ctx context.Context, userID, adID int64) (ads.Ad, error)
+	ListFavorites(ctx context.Context, userID int64) ([]ads.Ad, error)
+	// Переписку по объявлению начинает покупатель через ContactSeller; дальше участники пишут
+	// в неё через SendMessage. Читать переписку и отмечать её прочитанной могут только
+	// участники, список переписок (ListThreads) - только сам пользователь userID.
+	ContactSeller(ctx context.Context, adID int64, text string) (chat.Thread, chat.Message, error)
+	SendMessage(ctx context.Context, threadID int64, text string) (chat.Message, error)
+	ListMessages(ctx context.Context, threadID int64) ([]chat.Message, error)
+	// MarkThreadRead отмечает прочитанными полученные сообщения с ID не больше upTo
+	// (ReadAll - все) и возвращает отмеченные.
+	MarkThreadRead(ctx context.Context, threadID int64, upTo int64) ([]chat.Message, error)
+	ListThreads(ctx context.Context, userID int64) ([]chat.Summary, error)
+	WatchChats(ctx context.Context) (*ChatSubscription, error)
+	// Stats считает объявления и пользователей для метрик сервиса; проверки доступа нет.
+	Stats(ctx context.Context) (Stats, error)
 }
 
+// AnyVersion отключает проверку версии объявления при изменении.
+const AnyVersion int64 = 0
+
+func checkVersion(ad ads.Ad, version int64) error {
+	if version != AnyVersion && ad.Version != version {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// Repository и Users возвращают ErrNotFound для отсутствующих записей. Удалённые записи
+// считаются отсутствующими всеми методами, кроме Restore и Purge, а в выборки попадают
+// только по Filter.Deleted.
 type Repository interface {
-	Find(ctx context.Context, adID int64) (ads.Ad, bool)
-	Add(ctx context.Context, title string, text string, userID int64) ads.Ad
-	ChangeTitle(ctx context.Context, adID int64, title string) ads.Ad
-	ChangeText(ctx context.Context, adID int64, text string) ads.Ad
-	ChangeStatus(ctx context.Context, adID int64, status bool) ads.Ad
-	GetByTitle(ctx context.Context, title string) []ads.Ad
-	GetAdsByFilter(ctx context.Context, filter Filter) ([]ads.Ad, error)
-	Delete(ctx context.Context, adID int64) error
+	Find(ctx context.Context, adID int64) (ads.Ad, error)
+	// Add вместе с объявлением сохраняет первую версию его содержимого.
+	Add(ctx context.Context, title string, text string, attrs ads.Attributes, userID int64) (ads.Ad, error)
+	// Update передаёт mutate текущее объявление и сохраняет результат одной атомарной операцией:
+	// никто не увидит объявление изменённым наполовину. Version увеличивается на единицу,
+	// UpdateDate обновляется, Published выставляется по Status; ID, AuthorID, CreationDate
+	// и DeletedAt не меняются. Ошибка mutate отменяет изменение и возвращается как есть.
+	// Если mutate изменил Title или Text, в той же операции сохраняется новая версия
+	// содержимого; редактором в ней записывается автор объявления.
+	Update(ctx context.Context, adID int64, mutate func(ad *ads.Ad) error) (ads.Ad, error)
+	GetByTitle(ctx context.Context, title string, page Page) ([]ads.Ad, error)
+	GetAdsByFilter(ctx context.Context, filter Filter, page Page) ([]ads.Ad, error)
+	// Search возвращает до limit подходящих под filter объявлений, содержащих все слова
+	// запроса, в порядке убывания релевантности.
+	Search(ctx context.Context, query string, filter Filter, limit int) ([]SearchResult, error)
+	// Delete помечает объявление удалённым, если check для него не вернул ошибку; проверка
+	// и удаление выполняются атомарно.
+	Delete(ctx context.Context, adID int64, check func(ad ads.Ad) error) (ads.Ad, error)
+	// Restore снимает пометку об удалении, если check для удалённого объявления не вернул ошибку.
+	Restore(ctx context.Context, adID int64, check func(ad ads.Ad) error) (ads.Ad, error)
+	// DeleteByAuthor помечает удалёнными в момент at все объявления автора,
+	// RestoreByAuthor восстанавливает те из них, что удалены ровно в момент at.
+	DeleteByAuthor(ctx context.Context, authorID int64, at time.Time) ([]ads.Ad, error)
+	RestoreByAuthor(ctx context.Context, authorID int64, at time.Time) ([]ads.Ad, error)
+	// Purge окончательно удаляет объявления, удалённые раньше before, вместе с их версиями,
+	// и возвращает их.
+	Purge(ctx context.Context, before time.Time) ([]ads.Ad, error)
+	// Revisions возвращает версии объявления по возрастанию номера.
+	Revisions(ctx context.Context, adID int64) ([]ads.Revision, error)
+	Revision(ctx context.Context, adID int64, number int64) (ads.Revision, error)
+	// CountByStatus считает неудалённые объявления в каждом состоянии.
+	CountByStatus(ctx context.Context) (map[ads.Status]int64, error)
 }
 
 type Users interface {
-	Find(ctx context.Context, userID int64) (int64, bool)
-	Create(ctx context.Context, nickname, email string, userID int64) user.User
-	ChangeInfo(ctx context.Context, userID int64, nickname, email string) user.User
+	Get(ctx context.Context, userID int64) (user.User, error)
+	// Create при userID == 0 выделяет новый ID и запоминает время регистрации. Возвращает
+	// ErrAlreadyExists, если заняты ID, никнейм или email; никнейм и email сравниваются без
+	// учёта регистра. Удалённый пользователь занимает их до очистки.
+	Create(ctx context.Context, nickname, email, passwordHash string, userID int64) (user.User, error)
+	// ChangeInfo, как и Create, возвращает ErrAlreadyExists для чужих никнейма или email.
+	ChangeInfo(ctx context.Context, userID int64, nickname, email string, profile user.Profile) (user.User, error)
 	DeleteByID(ctx context.Context, userID int64) (user.User, error)
-}
-
-type Filter interface {
-	DefaultFilter(ctx context.Context) (Filter, error)
-	FilterByAuthor(ctx context.Context, userID int64) (Filter, error)
-	GetFilter(ctx context.Context) (Filter, error)
+	// Restore снимает пометку об удалении, если check для удалённого пользователя не вернул ошибку.
+	Restore(ctx context.Context, userID int64, check func(u user.User) error) (user.User, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	// PasswordHash, как и Get, возвращает ErrNotFound только для отсутствующего пользователя.
+	PasswordHash(ctx context.Context, userID int64) (string, error)
+	// Count считает неудалённых пользователей.
+	Count(ctx context.Context) (int64, error)
 }
 
 type StApp struct {
 	repository Repository
 	users      Users
-	filter     Filter
+	favorites  Favorites
+	chats      Chats
+	events     *Bus
+	chatEvents *chatBus
+	moderators map[int64]struct{}
+	admins     map[int64]struct{}
+	// importUsers разрешает любому клиенту задавать ID при регистрации
+	importUsers bool
+	blobs       BlobStore
+	logger      *zap.Logger
 }
 
-func NewApp(repo Repository, users Users, filter Filter) App {
-	return StApp{
-		repository: repo,
-		users:      users,
-		filter:     filter,
+func NewApp(repo Repository, users Users, favorites Favorites, chats Chats, opts ...Option) App {
+	s := StApp{
+		repository: tracedRepository{repo},
+		users:      tracedUsers{users},
+		favorites:  tracedFavorites{favorites},
+		chats:      tracedChats{chats},
+		events:     NewBus(DefaultEventBuffer),
+		chatEvents: newChatBus(DefaultEventBuffer),
+		moderators: map[int64]struct{}{},
+		admins:     map[int64]struct{}{},
+		logger:     zap.NewNop(),
 	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return tracedApp{s}
+}
+
+func (s StApp) publish(ctx context.Context, t EventType, ad ads.Ad) {
+	s.log(ctx).Debug("ad event", zap.Stringer("type", t), zap.Int64("ad_id", ad.ID))
+	s.events.Publish(AdEvent{Type: t, Ad: ad, Time: time.Now().UTC()})
+}
+
+func (s StApp) WatchAds(ctx context.Context, filter Filter) *Subscription {
+	return s.events.Subscribe(ctx, filter)
 }
 
-var ErrWrongFormat = errors.New("validate error")
-var ErrAccessDenied = errors.New("AccessDenied")
-var ErrApp = errors.New("unknown error")
+type credentials struct {
+	Password string `validate:"range:8,72"`
+}
+
+// caller возвращает ID аутентифицированного пользователя, от имени которого выполняется вызов.
+func (s StApp) caller(ctx context.Context) (int64, error) {
+	userID, ok := UserFromContext(ctx)
+	if !ok {
+		return 0, ErrUnauthenticated
+	}
+	// Токен мог пережить удалённого пользователя
+	if _, err := s.users.Get(ctx, userID); errors.Is(err, ErrNotFound) {
+		return 0, ErrUnauthenticated
+	} else if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
 
-func (s StApp) CreateAd(ctx context.Context, title string, text string, userID int64) (ads.Ad, error) {
-	_, isFound := s.users.Find(ctx, userID)
-	if !isFound {
-		return ads.Ad{}, ErrWrongFormat
+func (s StApp) CreateAd(ctx context.Context, title string, text string, attrs ads.Attributes) (ads.Ad, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return ads.Ad{}, err
 	}
 
+	attrs = normalizeAttributes(attrs)
 	ad := ads.Ad{
-		Title: title,
-		Text:  text,
+		Title:      title,
+		Text:       text,
+		Attributes: attrs,
 	}
-	err := validatorn.Validate(ad)
+	err = validateAd(ad)
 	if err != nil {
-		return ads.Ad{}, ErrWrongFormat
+		return ads.Ad{}, err
 	}
 
-	ad = s.repository.Add(ctx, title, text, userID)
+	ad, err = s.repository.Add(ctx, title, text, attrs, userID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	s.publish(ctx, EventCreated, ad)
 	return ad, nil
 }
 
-func (s StApp) ChangeAdStatus(ctx context.Context, adID int64, UserID int64, published bool) (ads.Ad, error) {
-	_, isFound := s.users.Find(ctx, UserID)
-	if !isFound {
-		return ads.Ad{}, ErrWrongFormat
+func (s StApp) ChangeAdStatus(ctx context.Context, adID int64, status ads.Status, reason string, version int64) (ads.Ad, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return ads.Ad{}, err
 	}
 
-	ad, isFound := s.repository.Find(ctx, adID)
-	if !isFound {
-		return ads.Ad{}, ErrWrongFormat
+	if status != ads.StatusRejected {
+		reason = ""
 	}
 
-	if ad.AuthorID != UserID {
-		return ads.Ad{}, ErrAccessDenied
+	var from ads.Status
+	ad, err := s.repository.Update(ctx, adID, func(ad *ads.Ad) error {
+		if err := s.checkTransition(userID, *ad, status, reason); err != nil {
+			return err
+		}
+		if err := checkVersion(*ad, version); err != nil {
+			return err
+		}
+		from = ad.Status
+		ad.Status = status
+		ad.RejectReason = reason
+		return nil
+	})
+	if err != nil {
+		return ads.Ad{}, err
 	}
-	ad = s.repository.ChangeStatus(ctx, adID, published)
+	s.publish(ctx, statusEvent(from, status), ad)
 	return ad, nil
 }
 
-func (s StApp) UpdateAd(ctx context.Context, adID int64, UserID int64, title string, text string) (ads.Ad, error) {
-	_, isFound := s.users.Find(ctx, UserID)
-	if !isFound {
-		return ads.Ad{}, ErrWrongFormat
-	}
-	ad, isFound := s.repository.Find(ctx, adID)
-	if !isFound {
-		return ads.Ad{}, ErrWrongFormat
-	}
-	if ad.AuthorID != UserID {
-		return ads.Ad{}, ErrAccessDenied
-	}
-	add := ads.Ad{
-		Title: title,
-		Text:  text,
+func (s StApp) UpdateAd(ctx context.Context, adID int64, title string, text string, attrs *ads.Attributes, version int64) (ads.Ad, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return ads.Ad{}, err
 	}
-	err := validatorn.Validate(add)
+	return s.edit(ctx, userID, adID, title, text, attrs, version)
+}
+
+// edit меняет содержимое объявления автора editorID и записывает его как новую версию.
+// Атрибуты в версии не хранятся; при attrs == nil они остаются прежними.
+func (s StApp) edit(ctx context.Context, editorID, adID int64, title, text string, attrs *ads.Attributes, version int64) (ads.Ad, error) {
+	var event EventType
+	ad, err := s.repository.Update(ctx, adID, func(ad *ads.Ad) error {
+		if ad.AuthorID != editorID {
+			return ErrAccessDenied
+		}
+		changed := ads.Ad{Title: title, Text: text, Attributes: ad.Attributes}
+		if attrs != nil {
+			changed.Attributes = normalizeAttributes(*attrs)
+		}
+		if err := validateAd(changed); err != nil {
+			return err
+		}
+		if err := checkVersion(*ad, version); err != nil {
+			return err
+		}
+		ad.Title = title
+		ad.Text = text
+		ad.Attributes = changed.Attributes
+		event = resubmit(ad)
+		return nil
+	})
 	if err != nil {
-		return ads.Ad{}, ErrWrongFormat
+		return ads.Ad{}, err
 	}
-	ad = s.repository.ChangeText(ctx, adID, text)
-	ad = s.repository.ChangeTitle(ctx, adID, title)
+	s.publish(ctx, event, ad)
 	return ad, nil
 }
 
-func (s StApp) DeleteAd(ctx context.Context, adID, userID int64) (ads.Ad, error) {
-	ad, isFound := s.repository.Find(ctx, adID)
-	if !isFound {
-		return ads.Ad{}, ErrWrongFormat
+func (s StApp) GetAd(ctx context.Context, adID int64) (ads.Ad, error) {
+	return s.repository.Find(ctx, adID)
+}
+
+func (s StApp) ListAdRevisions(ctx context.Context, adID int64) ([]ads.Revision, error) {
+	if _, err := s.repository.Find(ctx, adID); err != nil {
+		return nil, err
+	}
+	return s.repository.Revisions(ctx, adID)
+}
+
+func (s StApp) RestoreAdRevision(ctx context.Context, adID int64, number int64) (ads.Ad, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	ad, err := s.repository.Find(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, err
 	}
 	if ad.AuthorID != userID {
 		return ads.Ad{}, ErrAccessDenied
 	}
-	_ = s.repository.Delete(ctx, adID)
+	rev, err := s.repository.Revision(ctx, adID, number)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	return s.edit(ctx, userID, adID, rev.Title, rev.Text, nil, AnyVersion)
+}
+
+func (s StApp) DeleteAd(ctx context.Context, adID int64, version int64) (ads.Ad, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	ad, err := s.repository.Delete(ctx, adID, func(ad ads.Ad) error {
+		if ad.AuthorID != userID {
+			return ErrAccessDenied
+		}
+		return checkVersion(ad, version)
+	})
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	if err := s.favorites.RemoveAd(ctx, adID); err != nil {
+		return ads.Ad{}, err
+	}
+	s.publish(ctx, EventDeleted, ad)
 
 	return ad, nil
 }
 
-func (s StApp) CreateUser(ctx context.Context, nickname, email string, userID int64) (user.User, error) {
-	_, isFound := s.users.Find(ctx, userID)
-	if isFound {
-		return user.User{}, ErrWrongFormat
+func (s StApp) CreateUser(ctx context.Context, nickname, email, password string, userID int64) (user.User, error) {
+	if userID < 0 {
+		return user.User{}, &ValidationError{Fields: []FieldError{{Field: "user_id", Reason: "must be positive"}}}
+	}
+	if userID != 0 && !s.importUsers && s.admin(ctx) != nil {
+		return user.User{}, ErrAccessDenied
 	}
-	us := s.users.Create(ctx, nickname, email, userID)
+	fields := invalidFields(user.User{Nickname: nickname, Email: email})
+	fields = append(fields, invalidFields(credentials{Password: password})...)
+	if len(fields) > 0 {
+		return user.User{}, &ValidationError{Fields: fields}
+	}
+	// Проверка до дорогого хеширования; гонку двух запросов разрешает Users.Create
+	if userID != 0 {
+		if _, err := s.users.Get(ctx, userID); err == nil {
+			return user.User{}, ErrAlreadyExists
+		} else if !errors.Is(err, ErrNotFound) {
+			return user.User{}, err
+		}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return user.User{}, err
+	}
+	return s.users.Create(ctx, nickname, email, string(hash), userID)
+}
 
-	return us, nil
+func (s StApp) Authenticate(ctx context.Context, userID int64, password string) (user.User, error) {
+	hash, err := s.users.PasswordHash(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return user.User{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return user.User{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return user.User{}, ErrUnauthenticated
+	}
+	return user.User{ID: userID}, nil
 }
 
-func (s StApp) ChangeUserInfo(ctx context.Context, userID int64, nickname, email string) (user.User, error) {
-	_, isFound := s.users.Find(ctx, userID)
-	if !isFound {
-		return user.User{}, ErrWrongFormat
+// ChangeUserInfo и DeleteUser доступны только самому пользователю.
+func (s StApp) ChangeUserInfo(ctx context.Context, userID int64, nickname, email string, profile *user.Profile) (user.User, error) {
+	callerID, err := s.caller(ctx)
+	if err != nil {
+		return user.User{}, err
+	}
+	if callerID != userID {
+		return user.User{}, ErrAccessDenied
 	}
-	us := s.users.ChangeInfo(ctx, userID, nickname, email)
-	return us, nil
+	if profile == nil {
+		cur, err := s.users.Get(ctx, userID)
+		if err != nil {
+			return user.User{}, err
+		}
+		profile = &cur.Profile
+	}
+	if err := validate(user.User{Nickname: nickname, Email: email, Profile: *profile}); err != nil {
+		return user.User{}, err
+	}
+	return s.users.ChangeInfo(ctx, userID, nickname, email, *profile)
 }
+
 func (s StApp) DeleteUser(ctx context.Context, userID int64) (user.User, error) {
-	_, isFound := s.users.Find(ctx, userID)
-	if !isFound {
-		return user.User{}, ErrWrongFormat
+	callerID, err := s.caller(ctx)
+	if err != nil {
+		return user.User{}, err
+	}
+	if callerID != userID {
+		return user.User{}, ErrAccessDenied
+	}
+	u, err := s.users.DeleteByID(ctx, userID)
+	if err != nil {
+		return user.User{}, err
+	}
+	// Объявления удаляются с той же отметкой времени, по ней RestoreUser найдёт их
+	adss, err := s.repository.DeleteByAuthor(ctx, userID, *u.DeletedAt)
+	if err != nil {
+		return user.User{}, err
+	}
+	for _, ad := range adss {
+		if err := s.favorites.RemoveAd(ctx, ad.ID); err != nil {
+			return user.User{}, err
+		}
+		s.publish(ctx, EventDeleted, ad)
 	}
-	u, _ := s.users.DeleteByID(ctx, userID)
 	return u, nil
 }
 
-func (s StApp) NewFilter(ctx context.Context) (Filter, error) {
-	f, _ := s.filter.DefaultFilter(ctx)
-	return f, nil
+func (s StApp) GetAllAdsByFilter(ctx context.Context, filter Filter, p Pagination) (AdsPage, error) {
+	if err := filter.check(); err != nil {
+		return AdsPage{}, err
+	}
+	page, err := p.page()
+	if err != nil {
+		return AdsPage{}, err
+	}
+	adss, err := s.repository.GetAdsByFilter(ctx, filter, page)
+	if err != nil {
+		return AdsPage{}, err
+	}
+	return page.result(adss), nil
 }
 
-func (s StApp) GetAllAdsByFilter(ctx context.Context, filter Filter) ([]ads.Ad, error) {
-	res, _ := s.repository.GetAdsByFilter(ctx, filter)
-	return res, nil
+func (s StApp) FindUser(ctx context.Context, userID int64) (user.User, bool) {
+	u, err := s.users.Get(ctx, userID)
+	return u, err == nil
 }
 
-func CheckAd(ad ads.Ad, filter Filter) bool {
-	return ad.Published
+func (s StApp) GetUser(ctx context.Context, userID int64) (user.User, error) {
+	u, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return user.User{}, err
+	}
+	if callerID, ok := UserFromContext(ctx); ok && callerID == userID {
+		return u, nil
+	}
+	if s.admin(ctx) != nil {
+		u.Email = ""
+	}
+	return u, nil
 }
 
-func (s StApp) FindUser(ctx context.Context, userID int64) (int64, bool) {
-	u, isFound := s.users.Find(ctx, userID)
-	return u, isFound
+// ListUserAds для удалённого или несуществующего пользователя возвращает ErrNotFound.
+func (s StApp) ListUserAds(ctx context.Context, userID int64, p Pagination) (AdsPage, error) {
+	if _, err := s.users.Get(ctx, userID); err != nil {
+		return AdsPage{}, err
+	}
+	return s.GetAllAdsByFilter(ctx, NewFilter(WithAuthor(userID), WithPublished(OnlyPublished)), p)
+}
+
+func (s StApp) GetAdsByTitle(ctx context.Context, title string, p Pagination) (AdsPage, error) {
+	page, err := p.page()
+	if err != nil {
+		return AdsPage{}, err
+	}
+	adss, err := s.repository.GetByTitle(ctx, title, page)
+	if err != nil {
+		return AdsPage{}, err
+	}
+	return page.result(adss), nil
 }
 
-func (s StApp) GetAdsByTitle(ctx context.Context, title string) ([]ads.Ad, error) {
-	adss := s.repository.GetByTitle(ctx, title)
-	return adss, nil
+func (s StApp) SearchAds(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	if len(Tokenize(query)) == 0 || limit < 0 || limit > MaxPageLimit {
+		return nil, ErrWrongFormat
+	}
+	if limit == 0 {
+		limit = DefaultPageLimit
+	}
+	return s.repository.Search(ctx, query, NewFilter(), limit)
 }
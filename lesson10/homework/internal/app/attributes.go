@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"homework10/internal/ads"
+)
+
+// MaxTags - сколько меток можно указать у одного объявления.
+const MaxTags = 10
+
+// normalizeAttributes приводит метки к нижнему регистру и убирает повторы, а валюту - к верхнему.
+// Валюта цены без суммы не сохраняется, чтобы такое объявление не находилось по валюте.
+func normalizeAttributes(attrs ads.Attributes) ads.Attributes {
+	attrs.Tags = normalizeTags(attrs.Tags)
+	attrs.Price.Currency = strings.ToUpper(strings.TrimSpace(attrs.Price.Currency))
+	if attrs.Price.Amount == 0 {
+		attrs.Price.Currency = ""
+	}
+	attrs.Location = strings.TrimSpace(attrs.Location)
+	return attrs
+}
+
+func normalizeTags(tags []string) []string {
+	res := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		res = append(res, tag)
+	}
+	return res
+}
+
+// checkAttributes - проверки атрибутов, которые не выразить тегами validate.
+func checkAttributes(attrs ads.Attributes) []FieldError {
+	var fields []FieldError
+	if attrs.Category != "" && !ads.KnownCategory(attrs.Category) {
+		fields = append(fields, FieldError{Field: "category", Reason: "unknown category"})
+	}
+	if len(attrs.Tags) > MaxTags {
+		fields = append(fields, FieldError{Field: "tags", Reason: fmt.Sprintf("at most %d tags per ad", MaxTags)})
+	}
+	switch {
+	case attrs.Price.Amount < 0:
+		fields = append(fields, FieldError{Field: "price", Reason: "amount must not be negative"})
+	case attrs.Price.Amount > 0 && !ads.Currencies[attrs.Price.Currency]:
+		fields = append(fields, FieldError{Field: "price", Reason: "unknown currency"})
+	}
+	return fields
+}
+
+// validateAd проверяет содержимое и атрибуты объявления вместе, чтобы клиент сразу узнал обо всех ошибках.
+func validateAd(ad ads.Ad) error {
+	fields := append(invalidFields(ad), checkAttributes(ad.Attributes)...)
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
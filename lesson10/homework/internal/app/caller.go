@@ -0,0 +1,16 @@
+package app
+
+import "context"
+
+type userKey struct{}
+
+// WithUser кладёт в контекст ID аутентифицированного пользователя. Вызывается
+// транспортным слоем после проверки токена.
+func WithUser(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+func UserFromContext(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(userKey{}).(int64)
+	return userID, ok
+}
@@ -0,0 +1,238 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"homework10/internal/chat"
+)
+
+// Chats хранит переписку покупателей с авторами объявлений. Thread возвращает ErrNotFound
+// для отсутствующей переписки.
+type Chats interface {
+	// OpenThread возвращает переписку покупателя buyerID по объявлению adID, создавая её при необходимости.
+	OpenThread(ctx context.Context, adID, buyerID, sellerID int64) (chat.Thread, error)
+	Thread(ctx context.Context, threadID int64) (chat.Thread, error)
+	// AddMessage сохраняет сообщение и переносит UpdateDate переписки на время его отправки.
+	AddMessage(ctx context.Context, threadID, senderID int64, text string) (chat.Message, error)
+	// Messages возвращает сообщения переписки в порядке отправки.
+	Messages(ctx context.Context, threadID int64) ([]chat.Message, error)
+	// MarkRead отмечает прочитанными в момент at ещё не прочитанные сообщения переписки
+	// с ID не больше upTo, отправленные не readerID, и возвращает их.
+	MarkRead(ctx context.Context, threadID, readerID, upTo int64, at time.Time) ([]chat.Message, error)
+	// Inbox возвращает переписки пользователя, в которых есть сообщения, начиная с
+	// последней по времени сообщения.
+	Inbox(ctx context.Context, userID int64) ([]chat.Summary, error)
+}
+
+type ChatEventType int
+
+const (
+	ChatEventMessage ChatEventType = iota + 1
+	ChatEventRead
+)
+
+// ChatEvent получают оба участника переписки: новое сообщение или сообщения,
+// которые получатель отметил прочитанными.
+type ChatEvent struct {
+	Type     ChatEventType
+	Thread   chat.Thread
+	Messages []chat.Message
+	Time     time.Time
+}
+
+// ReadAll в MarkThreadRead отмечает прочитанными все полученные сообщения.
+const ReadAll int64 = 0
+
+// ContactSeller отправляет сообщение автору объявления adID от имени вызывающего,
+// открывая переписку при первом обращении. Написать самому себе нельзя, а по
+// неопубликованному объявлению - никому: для покупателей его нет (ErrNotFound).
+func (s StApp) ContactSeller(ctx context.Context, adID int64, text string) (chat.Thread, chat.Message, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return chat.Thread{}, chat.Message{}, err
+	}
+	if err := validate(chat.Message{Text: text}); err != nil {
+		return chat.Thread{}, chat.Message{}, err
+	}
+	ad, err := s.repository.Find(ctx, adID)
+	if err != nil {
+		return chat.Thread{}, chat.Message{}, err
+	}
+	if !ad.Published {
+		return chat.Thread{}, chat.Message{}, ErrNotFound
+	}
+	if ad.AuthorID == userID {
+		return chat.Thread{}, chat.Message{}, ErrAccessDenied
+	}
+	thread, err := s.chats.OpenThread(ctx, adID, userID, ad.AuthorID)
+	if err != nil {
+		return chat.Thread{}, chat.Message{}, err
+	}
+	msg, err := s.send(ctx, thread, userID, text)
+	return thread, msg, err
+}
+
+// SendMessage пишет в переписку, пока объявление не удалено; после удаления переписку
+// можно только читать, а отправка возвращает ErrNotFound.
+func (s StApp) SendMessage(ctx context.Context, threadID int64, text string) (chat.Message, error) {
+	userID, thread, err := s.participant(ctx, threadID)
+	if err != nil {
+		return chat.Message{}, err
+	}
+	if err := validate(chat.Message{Text: text}); err != nil {
+		return chat.Message{}, err
+	}
+	if _, err := s.repository.Find(ctx, thread.AdID); err != nil {
+		return chat.Message{}, err
+	}
+	return s.send(ctx, thread, userID, text)
+}
+
+func (s StApp) send(ctx context.Context, thread chat.Thread, senderID int64, text string) (chat.Message, error) {
+	msg, err := s.chats.AddMessage(ctx, thread.ID, senderID, text)
+	if err != nil {
+		return chat.Message{}, err
+	}
+	thread.UpdateDate = msg.CreationDate
+	s.chatEvents.publish(ChatEvent{Type: ChatEventMessage, Thread: thread, Messages: []chat.Message{msg}, Time: msg.CreationDate})
+	return msg, nil
+}
+
+func (s StApp) ListMessages(ctx context.Context, threadID int64) ([]chat.Message, error) {
+	_, _, err := s.participant(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	return s.chats.Messages(ctx, threadID)
+}
+
+func (s StApp) MarkThreadRead(ctx context.Context, threadID int64, upTo int64) ([]chat.Message, error) {
+	userID, thread, err := s.participant(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	msgs, err := s.chats.MarkRead(ctx, threadID, userID, upTo, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) > 0 {
+		s.chatEvents.publish(ChatEvent{Type: ChatEventRead, Thread: thread, Messages: msgs, Time: now})
+	}
+	return msgs, nil
+}
+
+func (s StApp) ListThreads(ctx context.Context, userID int64) ([]chat.Summary, error) {
+	if err := s.self(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.chats.Inbox(ctx, userID)
+}
+
+// participant возвращает вызывающего и переписку, если он в ней участвует.
+func (s StApp) participant(ctx context.Context, threadID int64) (int64, chat.Thread, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return 0, chat.Thread{}, err
+	}
+	thread, err := s.chats.Thread(ctx, threadID)
+	if err != nil {
+		return 0, chat.Thread{}, err
+	}
+	if !thread.Participant(userID) {
+		return 0, chat.Thread{}, ErrAccessDenied
+	}
+	return userID, thread, nil
+}
+
+// WatchChats подписывает вызывающего на события всех его переписок до отмены ctx.
+func (s StApp) WatchChats(ctx context.Context) (*ChatSubscription, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.chatEvents.subscribe(ctx, userID), nil
+}
+
+// chatBus рассылает события переписки подписчикам-участникам так же, как Bus:
+// publish не блокируется, а медленный подписчик отключается с ErrSlowConsumer.
+type chatBus struct {
+	mx     sync.Mutex
+	subs   map[*ChatSubscription]struct{}
+	buffer int
+}
+
+func newChatBus(buffer int) *chatBus {
+	return &chatBus{
+		subs:   map[*ChatSubscription]struct{}{},
+		buffer: buffer,
+	}
+}
+
+type ChatSubscription struct {
+	events chan ChatEvent
+	closed chan struct{}
+	userID int64
+	err    error
+}
+
+// Events закрывается при отмене контекста подписки или отключении подписчика; причину возвращает Err.
+func (s *ChatSubscription) Events() <-chan ChatEvent {
+	return s.events
+}
+
+// Err можно вызывать только после закрытия канала Events.
+func (s *ChatSubscription) Err() error {
+	return s.err
+}
+
+func (b *chatBus) subscribe(ctx context.Context, userID int64) *ChatSubscription {
+	sub := &ChatSubscription{
+		events: make(chan ChatEvent, b.buffer),
+		closed: make(chan struct{}),
+		userID: userID,
+	}
+
+	b.mx.Lock()
+	b.subs[sub] = struct{}{}
+	b.mx.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.mx.Lock()
+			b.remove(sub, ctx.Err())
+			b.mx.Unlock()
+		case <-sub.closed:
+		}
+	}()
+	return sub
+}
+
+func (b *chatBus) publish(event ChatEvent) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	for sub := range b.subs {
+		if !event.Thread.Participant(sub.userID) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			b.remove(sub, ErrSlowConsumer)
+		}
+	}
+}
+
+// remove вызывается под b.mx.
+func (b *chatBus) remove(sub *ChatSubscription, err error) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	sub.err = err
+	close(sub.closed)
+	close(sub.events)
+}
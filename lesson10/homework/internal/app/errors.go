@@ -0,0 +1,178 @@
+package app
+
+import (
+	"errors"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/gzesv/validatorn"
+)
+
+var (
+	// ErrWrongFormat - некорректные параметры запроса (лимит, курсор, поисковая строка).
+	ErrWrongFormat = errors.New("validate error")
+	// ErrValidation - значения полей не прошли проверку, подробности в ValidationError.
+	ErrValidation      = errors.New("validation failed")
+	ErrNotFound        = errors.New("not found")
+	ErrAlreadyExists   = errors.New("already exists")
+	ErrAccessDenied    = errors.New("AccessDenied")
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrInvalidTransition - объявление нельзя перевести в запрошенное состояние из текущего.
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrVersionConflict - объявление изменили после того, как клиент получил его версию.
+	ErrVersionConflict = errors.New("version conflict")
+	// ErrAuthorDeleted - объявление нельзя восстановить, пока удалён его автор.
+	ErrAuthorDeleted = errors.New("author is deleted")
+)
+
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+// ValidationError перечисляет поля, не прошедшие проверку; errors.Is(err, ErrValidation) для него истинно.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Reason)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// validate проверяет теги validate структуры v по одному полю за раз, чтобы
+// сообщить, какие именно поля не прошли проверку.
+func validate(v any) error {
+	if fields := invalidFields(v); len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// invalidFields обходит и встроенные структуры. Тег на срезе строк применяется к
+// каждому элементу: validatorn проверяет только строки. Правила тега разделяются ";",
+// для поля сообщается первое нарушенное.
+func invalidFields(v any) []FieldError {
+	value := reflect.ValueOf(v)
+	var fields []FieldError
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, invalidFields(value.Field(i).Interface())...)
+			continue
+		}
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		values := []reflect.Value{value.Field(i)}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Slice {
+			values = values[:0]
+			for j := 0; j < value.Field(i).Len(); j++ {
+				values = append(values, value.Field(i).Index(j))
+			}
+			fieldType = fieldType.Elem()
+		}
+	values:
+		for _, fieldValue := range values {
+			for _, rule := range strings.Split(tag, ";") {
+				if !checkRule(field.Name, fieldType, fieldValue, rule) {
+					fields = append(fields, FieldError{Field: fieldName(field.Name), Reason: reason(rule)})
+					break values
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// formats - правила тега validate, которых нет в validatorn.
+var formats = map[string]func(string) bool{
+	"email":    validEmail,
+	"nickname": validNickname,
+	"url":      validURL,
+}
+
+// fieldName переводит имя поля Go в имя поля API: AvatarURL - avatar_url.
+func fieldName(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// checkRule проверяет значение одного поля по одному правилу тега validate.
+func checkRule(name string, typ reflect.Type, value reflect.Value, rule string) bool {
+	if check, ok := formats[rule]; ok {
+		return value.Kind() == reflect.String && check(value.String())
+	}
+	single := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: name,
+		Type: typ,
+		Tag:  reflect.StructTag(`validate:"` + rule + `"`),
+	}})).Elem()
+	single.Field(0).Set(value)
+	return validatorn.Validate(single.Interface()) == nil
+}
+
+// validEmail принимает только сам адрес, без имени и угловых скобок, с точкой в домене.
+func validEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+	return strings.Contains(s[strings.LastIndex(s, "@"):], ".")
+}
+
+// validURL допускает пустую строку: ссылка необязательна.
+func validURL(s string) bool {
+	if s == "" {
+		return true
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validNickname(s string) bool {
+	for i, r := range s {
+		if unicode.IsLetter(r) || i > 0 && (unicode.IsDigit(r) || strings.ContainsRune("_.-", r)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// reason описывает правило тега validate, например "range:1,99".
+func reason(rule string) string {
+	name, args, _ := strings.Cut(rule, ":")
+	switch name {
+	case "range":
+		min, max, _ := strings.Cut(args, ",")
+		return "length must be between " + min + " and " + max
+	case "email":
+		return "must be a valid email address"
+	case "nickname":
+		return "must start with a letter and contain only letters, digits, '_', '.' and '-'"
+	case "url":
+		return "must be an http or https URL"
+	}
+	return "invalid value"
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"homework10/internal/ads"
+)
+
+type EventType int
+
+const (
+	EventCreated EventType = iota + 1
+	EventUpdated
+	EventPublished
+	EventUnpublished
+	EventDeleted
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventCreated:
+		return "created"
+	case EventUpdated:
+		return "updated"
+	case EventPublished:
+		return "published"
+	case EventUnpublished:
+		return "unpublished"
+	case EventDeleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+// DefaultEventBuffer - сколько событий может накопить подписчик, прежде чем будет отключён.
+const DefaultEventBuffer = 64
+
+// ErrSlowConsumer - подписчик не успевал забирать события и был отключён.
+var ErrSlowConsumer = errors.New("subscriber is too slow")
+
+type AdEvent struct {
+	Type EventType
+	Ad   ads.Ad
+	Time time.Time
+}
+
+// matches проверяет событие фильтром. Снятое с публикации объявление сравнивается
+// в опубликованном виде, а удалённое - в том виде, какое было до удаления, чтобы
+// подписчики узнали о его исчезновении.
+func (e AdEvent) matches(filter Filter) bool {
+	ad := e.Ad
+	switch e.Type {
+	case EventUnpublished:
+		ad.Published = true
+	case EventDeleted:
+		ad.DeletedAt = nil
+	}
+	return filter.Match(ad)
+}
+
+// Bus рассылает события объявлений подписчикам. Publish никогда не блокируется:
+// подписчик с переполненным буфером отключается с ErrSlowConsumer.
+type Bus struct {
+	mx     sync.Mutex
+	subs   map[*Subscription]struct{}
+	buffer int
+}
+
+func NewBus(buffer int) *Bus {
+	return &Bus{
+		subs:   map[*Subscription]struct{}{},
+		buffer: buffer,
+	}
+}
+
+type Subscription struct {
+	events chan AdEvent
+	closed chan struct{}
+	filter Filter
+	err    error
+}
+
+// Events закрывается при отмене контекста подписки или отключении подписчика; причину возвращает Err.
+func (s *Subscription) Events() <-chan AdEvent {
+	return s.events
+}
+
+// Err можно вызывать только после закрытия канала Events.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// Subscribe действует до отмены ctx.
+func (b *Bus) Subscribe(ctx context.Context, filter Filter) *Subscription {
+	sub := &Subscription{
+		events: make(chan AdEvent, b.buffer),
+		closed: make(chan struct{}),
+		filter: filter,
+	}
+
+	b.mx.Lock()
+	b.subs[sub] = struct{}{}
+	b.mx.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.mx.Lock()
+			b.remove(sub, ctx.Err())
+			b.mx.Unlock()
+		case <-sub.closed:
+		}
+	}()
+	return sub
+}
+
+func (b *Bus) Publish(event AdEvent) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	for sub := range b.subs {
+		if !event.matches(sub.filter) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			b.remove(sub, ErrSlowConsumer)
+		}
+	}
+}
+
+// remove вызывается под b.mx.
+func (b *Bus) remove(sub *Subscription, err error) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	sub.err = err
+	close(sub.closed)
+	close(sub.events)
+}
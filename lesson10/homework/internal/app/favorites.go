@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"homework10/internal/ads"
+)
+
+// Favorites хранит избранные объявления пользователей. Повторное добавление ошибкой
+// не считается; Remove возвращает ErrNotFound, если объявления нет в избранном.
+type Favorites interface {
+	Add(ctx context.Context, userID, adID int64) error
+	Remove(ctx context.Context, userID, adID int64) error
+	// List возвращает ID избранных объявлений, начиная с добавленного последним.
+	List(ctx context.Context, userID int64) ([]int64, error)
+	// RemoveAd убирает объявление из избранного всех пользователей.
+	RemoveAd(ctx context.Context, adID int64) error
+}
+
+// self проверяет, что вызов выполняет сам пользователь userID.
+func (s StApp) self(ctx context.Context, userID int64) error {
+	callerID, err := s.caller(ctx)
+	if err != nil {
+		return err
+	}
+	if callerID != userID {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+func (s StApp) AddFavorite(ctx context.Context, userID, adID int64) (ads.Ad, error) {
+	if err := s.self(ctx, userID); err != nil {
+		return ads.Ad{}, err
+	}
+	ad, err := s.repository.Find(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	if err := s.favorites.Add(ctx, userID, adID); err != nil {
+		return ads.Ad{}, err
+	}
+	return ad, nil
+}
+
+func (s StApp) RemoveFavorite(ctx context.Context, userID, adID int64) (ads.Ad, error) {
+	if err := s.self(ctx, userID); err != nil {
+		return ads.Ad{}, err
+	}
+	ad, err := s.repository.Find(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	if err := s.favorites.Remove(ctx, userID, adID); err != nil {
+		return ads.Ad{}, err
+	}
+	return ad, nil
+}
+
+// ListFavorites пропускает объявления, удалённые между добавлением в избранное и очисткой.
+func (s StApp) ListFavorites(ctx context.Context, userID int64) ([]ads.Ad, error) {
+	if err := s.self(ctx, userID); err != nil {
+		return nil, err
+	}
+	ids, err := s.favorites.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	adss := []ads.Ad{}
+	for _, id := range ids {
+		ad, err := s.repository.Find(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		adss = append(adss, ad)
+	}
+	return adss, nil
+}
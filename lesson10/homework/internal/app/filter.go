@@ -0,0 +1,248 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"homework10/internal/ads"
+)
+
+type PublishedState int
+
+const (
+	OnlyPublished PublishedState = iota
+	OnlyUnpublished
+	AnyPublished
+)
+
+// Filter описывает выборку объявлений. Нулевое значение выбирает все
+// опубликованные объявления; остальные условия добавляются через FilterOption.
+// Границы интервалов дат: From включительно, To не включительно, нулевое время
+// означает отсутствие границы. Keywords - слова, каждое из которых должно
+// встретиться в заголовке или тексте (см. Tokenize). Пустой Status - любое состояние.
+// Удалённые объявления выбираются только с Deleted, и тогда только они.
+// Category выбирает и вложенные категории, Tags - объявления со всеми перечисленными
+// метками. Цены сравниваются только в одной валюте: границы PriceMin и PriceMax
+// (включительно, 0 - без границы) требуют Currency и отсекают объявления без цены.
+type Filter struct {
+	Published     PublishedState
+	Status        ads.Status
+	AuthorID      *int64
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+	UpdatedFrom   time.Time
+	UpdatedTo     time.Time
+	TitleContains string
+	TextContains  string
+	Keywords      string
+	Deleted       bool
+	Category      string
+	Tags          []string
+	Currency      string
+	PriceMin      int64
+	PriceMax      int64
+	Location      string
+}
+
+type FilterOption func(*Filter)
+
+func NewFilter(opts ...FilterOption) Filter {
+	f := Filter{}
+	for _, opt := range opts {
+		opt(&f)
+	}
+	return f
+}
+
+func WithPublished(state PublishedState) FilterOption {
+	return func(f *Filter) {
+		f.Published = state
+	}
+}
+
+// WithStatus выбирает объявления в состоянии status, например очередь модерации.
+// Состояние само определяет, опубликовано ли объявление, поэтому условие на публикацию снимается.
+func WithStatus(status ads.Status) FilterOption {
+	return func(f *Filter) {
+		f.Status = status
+		f.Published = AnyPublished
+	}
+}
+
+func WithAuthor(userID int64) FilterOption {
+	return func(f *Filter) {
+		f.AuthorID = &userID
+	}
+}
+
+func CreatedBetween(from, to time.Time) FilterOption {
+	return func(f *Filter) {
+		f.CreatedFrom = from
+		f.CreatedTo = to
+	}
+}
+
+func UpdatedBetween(from, to time.Time) FilterOption {
+	return func(f *Filter) {
+		f.UpdatedFrom = from
+		f.UpdatedTo = to
+	}
+}
+
+func TitleContains(s string) FilterOption {
+	return func(f *Filter) {
+		f.TitleContains = s
+	}
+}
+
+func TextContains(s string) FilterOption {
+	return func(f *Filter) {
+		f.TextContains = s
+	}
+}
+
+func WithKeywords(s string) FilterOption {
+	return func(f *Filter) {
+		f.Keywords = s
+	}
+}
+
+// OnlyDeleted выбирает удалённые объявления, ожидающие очистки, в любом состоянии.
+func OnlyDeleted() FilterOption {
+	return func(f *Filter) {
+		f.Deleted = true
+		f.Published = AnyPublished
+	}
+}
+
+func InCategory(category string) FilterOption {
+	return func(f *Filter) {
+		f.Category = category
+	}
+}
+
+func WithTags(tags ...string) FilterOption {
+	return func(f *Filter) {
+		f.Tags = normalizeTags(tags)
+	}
+}
+
+func PriceBetween(currency string, min, max int64) FilterOption {
+	return func(f *Filter) {
+		f.Currency = strings.ToUpper(currency)
+		f.PriceMin = min
+		f.PriceMax = max
+	}
+}
+
+func AtLocation(location string) FilterOption {
+	return func(f *Filter) {
+		f.Location = location
+	}
+}
+
+// check проверяет сочетание условий: диапазон цен без валюты не имеет смысла.
+func (f Filter) check() error {
+	if (f.PriceMin != 0 || f.PriceMax != 0) && f.Currency == "" {
+		return fmt.Errorf("%w: price range requires currency", ErrWrongFormat)
+	}
+	if f.PriceMin < 0 || f.PriceMax < 0 {
+		return fmt.Errorf("%w: price must not be negative", ErrWrongFormat)
+	}
+	return nil
+}
+
+// Match сообщает, подходит ли объявление под фильтр. Подстроки сравниваются без учёта регистра.
+func (f Filter) Match(ad ads.Ad) bool {
+	if (ad.DeletedAt != nil) != f.Deleted {
+		return false
+	}
+	switch f.Published {
+	case OnlyPublished:
+		if !ad.Published {
+			return false
+		}
+	case OnlyUnpublished:
+		if ad.Published {
+			return false
+		}
+	}
+	if f.Status != "" && ad.Status != f.Status {
+		return false
+	}
+	if f.AuthorID != nil && ad.AuthorID != *f.AuthorID {
+		return false
+	}
+	if !inRange(ad.CreationDate, f.CreatedFrom, f.CreatedTo) || !inRange(ad.UpdateDate, f.UpdatedFrom, f.UpdatedTo) {
+		return false
+	}
+	if !containsFold(ad.Title, f.TitleContains) || !containsFold(ad.Text, f.TextContains) {
+		return false
+	}
+	if !matchAttributes(ad.Attributes, f) {
+		return false
+	}
+	return hasKeywords(ad, f.Keywords)
+}
+
+func matchAttributes(attrs ads.Attributes, f Filter) bool {
+	if f.Category != "" && !ads.InCategory(attrs.Category, f.Category) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !hasTag(attrs.Tags, tag) {
+			return false
+		}
+	}
+	if f.Currency != "" && attrs.Price.Currency != f.Currency {
+		return false
+	}
+	if f.PriceMin != 0 && attrs.Price.Amount < f.PriceMin {
+		return false
+	}
+	if f.PriceMax != 0 && (attrs.Price.Amount == 0 || attrs.Price.Amount > f.PriceMax) {
+		return false
+	}
+	return f.Location == "" || strings.EqualFold(attrs.Location, f.Location)
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func hasKeywords(ad ads.Ad, keywords string) bool {
+	terms := Tokenize(keywords)
+	if len(terms) == 0 {
+		return true
+	}
+	words := map[string]bool{}
+	for _, w := range append(Tokenize(ad.Title), Tokenize(ad.Text)...) {
+		words[w] = true
+	}
+	for _, term := range terms {
+		if !words[term] {
+			return false
+		}
+	}
+	return true
+}
+
+func inRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	if !to.IsZero() && !t.Before(to) {
+		return false
+	}
+	return true
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
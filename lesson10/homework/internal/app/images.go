@@ -0,0 +1,244 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/image/draw"
+
+	"homework10/internal/ads"
+)
+
+const (
+	// MaxImageSize - наибольший размер загружаемого изображения в байтах.
+	MaxImageSize = 5 << 20
+	// MaxImagesPerAd - сколько изображений можно прикрепить к одному объявлению.
+	MaxImagesPerAd = 10
+	// MaxImagePixels ограничивает размеры изображения: небольшой файл может
+	// распаковываться в огромную картинку.
+	MaxImagePixels = 40_000_000
+	// ThumbnailSize - наибольшая сторона миниатюры в пикселях.
+	ThumbnailSize = 320
+
+	thumbnailContentType = "image/jpeg"
+)
+
+var (
+	// ErrUnsupportedImage - содержимое не является изображением JPEG, PNG или GIF.
+	ErrUnsupportedImage = errors.New("unsupported image type")
+	ErrImageTooLarge    = fmt.Errorf("image is larger than %d bytes", MaxImageSize)
+)
+
+// Тип определяется по содержимому, заявленному клиентом типу не доверяем
+var imageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// BlobStore хранит содержимое файлов по ключу. Get для отсутствующего ключа
+// возвращает ErrNotFound, Delete отсутствующего ключа ошибкой не считается.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// WithBlobStore задаёт хранилище изображений объявлений. Без него загрузка изображений недоступна.
+func WithBlobStore(blobs BlobStore) Option {
+	return func(s *StApp) {
+		s.blobs = blobs
+	}
+}
+
+var errNoBlobStore = errors.New("blob store is not configured")
+
+// ImageURL - путь, по которому HTTP API отдаёт изображение объявления или его миниатюру.
+// Изображения отдаются только по HTTP, поэтому gRPC возвращает те же пути.
+func ImageURL(adID int64, img ads.Image, thumbnail bool) string {
+	url := fmt.Sprintf("/api/v1/ads/%d/images/%s", adID, img.ID)
+	if thumbnail {
+		url += "/thumbnail"
+	}
+	return url
+}
+
+// AddAdImage проверяет изображение из r, сохраняет его вместе с миниатюрой и прикрепляет к объявлению.
+func (s StApp) AddAdImage(ctx context.Context, adID int64, r io.Reader) (ads.Ad, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	if s.blobs == nil {
+		return ads.Ad{}, errNoBlobStore
+	}
+	// Проверки до чтения и обработки изображения; окончательно их повторит Update
+	ad, err := s.repository.Find(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	if err := checkImages(userID, ad); err != nil {
+		return ads.Ad{}, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxImageSize+1))
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	if len(data) > MaxImageSize {
+		return ads.Ad{}, ErrImageTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	if !imageTypes[contentType] {
+		return ads.Ad{}, ErrUnsupportedImage
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("%w: %s", ErrUnsupportedImage, err.Error())
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxImagePixels {
+		return ads.Ad{}, ErrImageTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("%w: %s", ErrUnsupportedImage, err.Error())
+	}
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, thumbnail(src), nil); err != nil {
+		return ads.Ad{}, err
+	}
+
+	id, err := newImageID()
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	img := ads.Image{
+		ID:           id,
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+		Width:        src.Bounds().Dx(),
+		Height:       src.Bounds().Dy(),
+		Key:          fmt.Sprintf("ads/%d/%s", adID, id),
+		ThumbnailKey: fmt.Sprintf("ads/%d/%s_thumbnail", adID, id),
+		CreationDate: time.Now().UTC(),
+	}
+	if err := s.blobs.Put(ctx, img.Key, bytes.NewReader(data)); err != nil {
+		return ads.Ad{}, err
+	}
+	if err := s.blobs.Put(ctx, img.ThumbnailKey, &thumb); err != nil {
+		s.deleteBlobs(ctx, img)
+		return ads.Ad{}, err
+	}
+
+	var event EventType
+	ad, err = s.repository.Update(ctx, adID, func(ad *ads.Ad) error {
+		if err := checkImages(userID, *ad); err != nil {
+			return err
+		}
+		// Новый срез: прежний может разделять память с уже выданными копиями объявления
+		ad.Images = append(append([]ads.Image(nil), ad.Images...), img)
+		event = resubmit(ad)
+		return nil
+	})
+	if err != nil {
+		s.deleteBlobs(ctx, img)
+		return ads.Ad{}, err
+	}
+	s.publish(ctx, event, ad)
+	return ad, nil
+}
+
+func checkImages(userID int64, ad ads.Ad) error {
+	if ad.AuthorID != userID {
+		return ErrAccessDenied
+	}
+	if len(ad.Images) >= MaxImagesPerAd {
+		return &ValidationError{Fields: []FieldError{{
+			Field:  "images",
+			Reason: fmt.Sprintf("at most %d images per ad", MaxImagesPerAd),
+		}}}
+	}
+	return nil
+}
+
+// AdImage открывает содержимое изображения объявления или его миниатюры и возвращает его тип.
+func (s StApp) AdImage(ctx context.Context, adID int64, imageID string, thumbnail bool) (io.ReadCloser, string, error) {
+	if s.blobs == nil {
+		return nil, "", ErrNotFound
+	}
+	ad, err := s.repository.Find(ctx, adID)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, img := range ad.Images {
+		if img.ID != imageID {
+			continue
+		}
+		if thumbnail {
+			r, err := s.blobs.Get(ctx, img.ThumbnailKey)
+			return r, thumbnailContentType, err
+		}
+		r, err := s.blobs.Get(ctx, img.Key)
+		return r, img.ContentType, err
+	}
+	return nil, "", ErrNotFound
+}
+
+// deleteBlobs удаляет файлы изображения. Ошибки только записываются в журнал: вызывающий
+// уже завершается другой ошибкой или очисткой, а оставшийся файл ничего не ломает.
+func (s StApp) deleteBlobs(ctx context.Context, img ads.Image) {
+	if s.blobs == nil {
+		return
+	}
+	for _, key := range []string{img.Key, img.ThumbnailKey} {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			s.log(ctx).Warn("can't delete blob", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// thumbnail уменьшает изображение так, чтобы большая сторона не превышала ThumbnailSize.
+// Маленькие изображения не увеличиваются.
+func thumbnail(src image.Image) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > ThumbnailSize || h > ThumbnailSize {
+		if w >= h {
+			w, h = ThumbnailSize, h*ThumbnailSize/w
+		} else {
+			w, h = w*ThumbnailSize/h, ThumbnailSize
+		}
+	}
+	// Очень вытянутое изображение не должно сжаться в нулевую сторону
+	if w == 0 {
+		w = 1
+	}
+	if h == 0 {
+		h = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	// Прозрачные области PNG и GIF в JPEG становятся белыми, а не чёрными
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+	return dst
+}
+
+func newImageID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
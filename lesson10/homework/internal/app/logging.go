@@ -0,0 +1,25 @@
+package app
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"homework10/internal/logging"
+)
+
+// WithLogger задаёт журнал приложения. Без него записи отбрасываются.
+func WithLogger(logger *zap.Logger) Option {
+	return func(s *StApp) {
+		s.logger = logger
+	}
+}
+
+// log возвращает журнал с ID запроса и пользователя, от имени которого выполняется вызов.
+func (s StApp) log(ctx context.Context) *zap.Logger {
+	l := logging.FromContext(ctx, s.logger)
+	if userID, ok := UserFromContext(ctx); ok {
+		l = l.With(zap.Int64("user_id", userID))
+	}
+	return l
+}
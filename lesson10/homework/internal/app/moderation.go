@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+
+	"homework10/internal/ads"
+)
+
+type role int
+
+const (
+	roleAuthor role = iota
+	roleModerator
+)
+
+type transition struct {
+	from, to ads.Status
+}
+
+// transitions перечисляет разрешённые смены состояния объявления и роль, которой они доступны.
+// Автор готовит объявление и отправляет его на проверку, модератор публикует или отклоняет.
+var transitions = map[transition]role{
+	{ads.StatusDraft, ads.StatusPending}:      roleAuthor,
+	{ads.StatusDraft, ads.StatusArchived}:     roleAuthor,
+	{ads.StatusPending, ads.StatusDraft}:      roleAuthor,
+	{ads.StatusPending, ads.StatusArchived}:   roleAuthor,
+	{ads.StatusRejected, ads.StatusDraft}:     roleAuthor,
+	{ads.StatusRejected, ads.StatusPending}:   roleAuthor,
+	{ads.StatusRejected, ads.StatusArchived}:  roleAuthor,
+	{ads.StatusPublished, ads.StatusArchived}: roleAuthor,
+	{ads.StatusArchived, ads.StatusDraft}:     roleAuthor,
+
+	{ads.StatusPending, ads.StatusPublished}:  roleModerator,
+	{ads.StatusPending, ads.StatusRejected}:   roleModerator,
+	{ads.StatusPublished, ads.StatusRejected}: roleModerator,
+}
+
+type rejection struct {
+	Reason string `validate:"range:1,499"`
+}
+
+type Option func(*StApp)
+
+// WithModerators назначает пользователей, которые могут публиковать и отклонять объявления.
+func WithModerators(userIDs ...int64) Option {
+	return func(s *StApp) {
+		for _, id := range userIDs {
+			s.moderators[id] = struct{}{}
+		}
+	}
+}
+
+// WithAdmins назначает пользователей, которые могут просматривать и восстанавливать удалённое.
+func WithAdmins(userIDs ...int64) Option {
+	return func(s *StApp) {
+		for _, id := range userIDs {
+			s.admins[id] = struct{}{}
+		}
+	}
+}
+
+// WithUserImport разрешает задавать ID при регистрации без прав администратора, например
+// при переносе пользователей из другой системы.
+func WithUserImport() Option {
+	return func(s *StApp) {
+		s.importUsers = true
+	}
+}
+
+func (s StApp) isModerator(userID int64) bool {
+	_, ok := s.moderators[userID]
+	return ok
+}
+
+// admin проверяет, что вызов выполняет администратор.
+func (s StApp) admin(ctx context.Context) error {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.admins[userID]; !ok {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// checkTransition проверяет, может ли пользователь userID перевести объявление ad в состояние to.
+func (s StApp) checkTransition(userID int64, ad ads.Ad, to ads.Status, reason string) error {
+	if !to.Valid() {
+		return &ValidationError{Fields: []FieldError{{Field: "status", Reason: "unknown status"}}}
+	}
+	if to == ads.StatusRejected {
+		if err := validate(rejection{Reason: reason}); err != nil {
+			return err
+		}
+	}
+
+	r, ok := transitions[transition{ad.Status, to}]
+	if !ok {
+		return ErrInvalidTransition
+	}
+	switch r {
+	case roleModerator:
+		if !s.isModerator(userID) {
+			return ErrAccessDenied
+		}
+	default:
+		if ad.AuthorID != userID {
+			return ErrAccessDenied
+		}
+	}
+	return nil
+}
+
+// resubmit возвращает изменённое автором опубликованное объявление на проверку, иначе
+// одобренное содержимое можно было бы подменить непроверенным. Возвращает событие изменения.
+func resubmit(ad *ads.Ad) EventType {
+	if ad.Status != ads.StatusPublished {
+		return EventUpdated
+	}
+	ad.Status = ads.StatusPending
+	return EventUnpublished
+}
+
+// statusEvent выбирает событие для смены состояния: подписчики на опубликованные
+// узнают о появлении и исчезновении объявления из ленты.
+func statusEvent(from, to ads.Status) EventType {
+	switch {
+	case to == ads.StatusPublished:
+		return EventPublished
+	case from == ads.StatusPublished:
+		return EventUnpublished
+	}
+	return EventUpdated
+}
@@ -0,0 +1,166 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"homework10/internal/ads"
+)
+
+type SortKey int
+
+const (
+	SortByID SortKey = iota
+	SortByCreationDate
+	SortByUpdateDate
+	SortByTitle
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 1000
+)
+
+// Pagination - параметры страницы, пришедшие от клиента. Cursor - непрозрачный
+// токен из AdsPage.NextCursor предыдущей страницы, пустой для первой страницы.
+type Pagination struct {
+	Limit  int
+	SortBy SortKey
+	Desc   bool
+	Cursor string
+}
+
+type AdsPage struct {
+	Ads        []ads.Ad
+	NextCursor string
+}
+
+// Cursor - позиция последнего отданного объявления. Сортировка всегда
+// дополняется ID, поэтому позиция однозначна даже при совпадающих ключах.
+type Cursor struct {
+	SortBy SortKey   `json:"s"`
+	Desc   bool      `json:"d,omitempty"`
+	ID     int64     `json:"i"`
+	Time   time.Time `json:"t,omitempty"`
+	Title  string    `json:"v,omitempty"`
+}
+
+// Page - разобранная Pagination, которую получает репозиторий. Репозиторий
+// должен вернуть до Limit+1 объявлений, идущих строго после After, чтобы
+// приложение могло понять, есть ли следующая страница.
+type Page struct {
+	Limit  int
+	SortBy SortKey
+	Desc   bool
+	After  *Cursor
+}
+
+func (p Pagination) page() (Page, error) {
+	if p.Limit < 0 || p.Limit > MaxPageLimit {
+		return Page{}, ErrWrongFormat
+	}
+	if p.SortBy < SortByID || p.SortBy > SortByTitle {
+		return Page{}, ErrWrongFormat
+	}
+
+	page := Page{Limit: p.Limit, SortBy: p.SortBy, Desc: p.Desc}
+	if page.Limit == 0 {
+		page.Limit = DefaultPageLimit
+	}
+	if p.Cursor == "" {
+		return page, nil
+	}
+
+	cursor, err := decodeCursor(p.Cursor)
+	if err != nil || cursor.SortBy != p.SortBy || cursor.Desc != p.Desc {
+		return Page{}, ErrWrongFormat
+	}
+	page.After = &cursor
+	return page, nil
+}
+
+// Less задаёт порядок объявлений на странице.
+func (p Page) Less(a, b ads.Ad) bool {
+	c := compare(p.SortBy, cursorOf(p.SortBy, a), cursorOf(p.SortBy, b))
+	if p.Desc {
+		return c > 0
+	}
+	return c < 0
+}
+
+// Follows сообщает, идёт ли объявление после курсора страницы.
+func (p Page) Follows(ad ads.Ad) bool {
+	if p.After == nil {
+		return true
+	}
+	c := compare(p.SortBy, *p.After, cursorOf(p.SortBy, ad))
+	if p.Desc {
+		return c > 0
+	}
+	return c < 0
+}
+
+func (p Page) result(adss []ads.Ad) AdsPage {
+	if len(adss) <= p.Limit {
+		return AdsPage{Ads: adss}
+	}
+	adss = adss[:p.Limit]
+	next := cursorOf(p.SortBy, adss[len(adss)-1])
+	next.Desc = p.Desc
+	return AdsPage{Ads: adss, NextCursor: encodeCursor(next)}
+}
+
+func cursorOf(sortBy SortKey, ad ads.Ad) Cursor {
+	c := Cursor{SortBy: sortBy, ID: ad.ID}
+	switch sortBy {
+	case SortByCreationDate:
+		c.Time = ad.CreationDate
+	case SortByUpdateDate:
+		c.Time = ad.UpdateDate
+	case SortByTitle:
+		c.Title = ad.Title
+	}
+	return c
+}
+
+func compare(sortBy SortKey, a, b Cursor) int {
+	switch sortBy {
+	case SortByCreationDate, SortByUpdateDate:
+		if a.Time.Before(b.Time) {
+			return -1
+		}
+		if a.Time.After(b.Time) {
+			return 1
+		}
+	case SortByTitle:
+		if a.Title < b.Title {
+			return -1
+		}
+		if a.Title > b.Title {
+			return 1
+		}
+	}
+	switch {
+	case a.ID < b.ID:
+		return -1
+	case a.ID > b.ID:
+		return 1
+	}
+	return 0
+}
+
+func encodeCursor(c Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, err
+	}
+	c := Cursor{}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
@@ -0,0 +1,23 @@
+package app
+
+import (
+	"strings"
+	"unicode"
+
+	"homework10/internal/ads"
+)
+
+// Вес совпадения в заголовке относительно совпадения в тексте объявления.
+const TitleWeight = 2
+
+type SearchResult struct {
+	Ad    ads.Ad
+	Score float64
+}
+
+// Tokenize разбивает строку на слова из букв и цифр любого алфавита и приводит их к нижнему регистру.
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"context"
+
+	"homework10/internal/ads"
+)
+
+// Stats - текущее число неудалённых объявлений по состояниям и пользователей.
+type Stats struct {
+	AdsByStatus map[ads.Status]int64
+	Users       int64
+}
+
+func (s StApp) Stats(ctx context.Context) (Stats, error) {
+	byStatus, err := s.repository.CountByStatus(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	users, err := s.users.Count(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	return Stats{AdsByStatus: byStatus, Users: users}, nil
+}
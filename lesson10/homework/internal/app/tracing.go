@@ -0,0 +1,485 @@
+package app
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"homework10/internal/ads"
+	"homework10/internal/chat"
+	"homework10/internal/tracing"
+	"homework10/internal/user"
+)
+
+// Обёртки ниже открывают span на каждый вызов App и хранилищ. NewApp оборачивает ими
+// приложение и все хранилища, так что в трассировке запроса видно, сколько времени
+// заняла бизнес-логика и сколько - каждое обращение к хранилищу.
+
+// tracedApp не трассирует WatchAds и WatchChats: подписка живёт дольше вызова.
+type tracedApp struct {
+	App
+}
+
+type tracedRepository struct {
+	Repository
+}
+
+type tracedUsers struct {
+	Users
+}
+
+type tracedFavorites struct {
+	Favorites
+}
+
+type tracedChats struct {
+	Chats
+}
+
+func (t tracedApp) CreateAd(ctx context.Context, title string, text string, attrs ads.Attributes) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "App.CreateAd")
+	ad, err := t.App.CreateAd(ctx, title, text, attrs)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedApp) ChangeAdStatus(ctx context.Context, adID int64, status ads.Status, reason string, version int64) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "App.ChangeAdStatus")
+	ad, err := t.App.ChangeAdStatus(ctx, adID, status, reason, version)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedApp) UpdateAd(ctx context.Context, adID int64, title string, text string, attrs *ads.Attributes, version int64) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "App.UpdateAd")
+	ad, err := t.App.UpdateAd(ctx, adID, title, text, attrs, version)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedApp) GetAd(ctx context.Context, adID int64) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "App.GetAd")
+	ad, err := t.App.GetAd(ctx, adID)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedApp) ListAdRevisions(ctx context.Context, adID int64) ([]ads.Revision, error) {
+	ctx, span := tracing.Start(ctx, "App.ListAdRevisions")
+	revs, err := t.App.ListAdRevisions(ctx, adID)
+	tracing.End(span, err)
+	return revs, err
+}
+
+func (t tracedApp) AddAdImage(ctx context.Context, adID int64, r io.Reader) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "App.AddAdImage")
+	ad, err := t.App.AddAdImage(ctx, adID, r)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedApp) AdImage(ctx context.Context, adID int64, imageID string, thumbnail bool) (io.ReadCloser, string, error) {
+	ctx, span := tracing.Start(ctx, "App.AdImage")
+	r, contentType, err := t.App.AdImage(ctx, adID, imageID, thumbnail)
+	tracing.End(span, err)
+	return r, contentType, err
+}
+
+func (t tracedApp) RestoreAdRevision(ctx context.Context, adID int64, number int64) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "App.RestoreAdRevision")
+	ad, err := t.App.RestoreAdRevision(ctx, adID, number)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedApp) GetAdsByTitle(ctx context.Context, title string, p Pagination) (AdsPage, error) {
+	ctx, span := tracing.Start(ctx, "App.GetAdsByTitle")
+	page, err := t.App.GetAdsByTitle(ctx, title, p)
+	tracing.End(span, err)
+	return page, err
+}
+
+func (t tracedApp) GetAllAdsByFilter(ctx context.Context, filter Filter, p Pagination) (AdsPage, error) {
+	ctx, span := tracing.Start(ctx, "App.GetAllAdsByFilter")
+	page, err := t.App.GetAllAdsByFilter(ctx, filter, p)
+	tracing.End(span, err)
+	return page, err
+}
+
+func (t tracedApp) SearchAds(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	ctx, span := tracing.Start(ctx, "App.SearchAds")
+	results, err := t.App.SearchAds(ctx, query, limit)
+	tracing.End(span, err)
+	return results, err
+}
+
+func (t tracedApp) ChangeUserInfo(ctx context.Context, userID int64, nickname, email string, profile *user.Profile) (user.User, error) {
+	ctx, span := tracing.Start(ctx, "App.ChangeUserInfo")
+	u, err := t.App.ChangeUserInfo(ctx, userID, nickname, email, profile)
+	tracing.End(span, err)
+	return u, err
+}
+
+func (t tracedApp) CreateUser(ctx context.Context, nickname, email, password string, userID int64) (user.User, error) {
+	ctx, span := tracing.Start(ctx, "App.CreateUser")
+	u, err := t.App.CreateUser(ctx, nickname, email, password, userID)
+	tracing.End(span, err)
+	return u, err
+}
+
+func (t tracedApp) Authenticate(ctx context.Context, userID int64, password string) (user.User, error) {
+	ctx, span := tracing.Start(ctx, "App.Authenticate")
+	u, err := t.App.Authenticate(ctx, userID, password)
+	tracing.End(span, err)
+	return u, err
+}
+
+func (t tracedApp) FindUser(ctx context.Context, userID int64) (user.User, bool) {
+	ctx, span := tracing.Start(ctx, "App.FindUser")
+	u, ok := t.App.FindUser(ctx, userID)
+	tracing.End(span, nil)
+	return u, ok
+}
+
+func (t tracedApp) GetUser(ctx context.Context, userID int64) (user.User, error) {
+	ctx, span := tracing.Start(ctx, "App.GetUser")
+	u, err := t.App.GetUser(ctx, userID)
+	tracing.End(span, err)
+	return u, err
+}
+
+func (t tracedApp) ListUserAds(ctx context.Context, userID int64, p Pagination) (AdsPage, error) {
+	ctx, span := tracing.Start(ctx, "App.ListUserAds")
+	page, err := t.App.ListUserAds(ctx, userID, p)
+	tracing.End(span, err)
+	return page, err
+}
+
+func (t tracedApp) DeleteAd(ctx context.Context, adID int64, version int64) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "App.DeleteAd")
+	ad, err := t.App.DeleteAd(ctx, adID, version)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedApp) DeleteUser(ctx context.Context, userID int64) (user.User, error) {
+	ctx, span := tracing.Start(ctx, "App.DeleteUser")
+	u, err := t.App.DeleteUser(ctx, userID)
+	tracing.End(span, err)
+	return u, err
+}
+
+func (t tracedApp) RestoreAd(ctx context.Context, adID int64) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "App.RestoreAd")
+	ad, err := t.App.RestoreAd(ctx, adID)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedApp) RestoreUser(ctx context.Context, userID int64) (user.User, error) {
+	ctx, span := tracing.Start(ctx, "App.RestoreUser")
+	u, err := t.App.RestoreUser(ctx, userID)
+	tracing.End(span, err)
+	return u, err
+}
+
+func (t tracedApp) ListDeletedAds(ctx context.Context, p Pagination) (AdsPage, error) {
+	ctx, span := tracing.Start(ctx, "App.ListDeletedAds")
+	page, err := t.App.ListDeletedAds(ctx, p)
+	tracing.End(span, err)
+	return page, err
+}
+
+func (t tracedApp) PurgeDeleted(ctx context.Context, before time.Time) (Purged, error) {
+	ctx, span := tracing.Start(ctx, "App.PurgeDeleted")
+	purged, err := t.App.PurgeDeleted(ctx, before)
+	tracing.End(span, err)
+	return purged, err
+}
+
+func (t tracedApp) AddFavorite(ctx context.Context, userID, adID int64) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "App.AddFavorite")
+	ad, err := t.App.AddFavorite(ctx, userID, adID)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedApp) RemoveFavorite(ctx context.Context, userID, adID int64) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "App.RemoveFavorite")
+	ad, err := t.App.RemoveFavorite(ctx, userID, adID)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedApp) ListFavorites(ctx context.Context, userID int64) ([]ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "App.ListFavorites")
+	adss, err := t.App.ListFavorites(ctx, userID)
+	tracing.End(span, err)
+	return adss, err
+}
+
+func (t tracedApp) ContactSeller(ctx context.Context, adID int64, text string) (chat.Thread, chat.Message, error) {
+	ctx, span := tracing.Start(ctx, "App.ContactSeller")
+	thread, msg, err := t.App.ContactSeller(ctx, adID, text)
+	tracing.End(span, err)
+	return thread, msg, err
+}
+
+func (t tracedApp) SendMessage(ctx context.Context, threadID int64, text string) (chat.Message, error) {
+	ctx, span := tracing.Start(ctx, "App.SendMessage")
+	msg, err := t.App.SendMessage(ctx, threadID, text)
+	tracing.End(span, err)
+	return msg, err
+}
+
+func (t tracedApp) ListMessages(ctx context.Context, threadID int64) ([]chat.Message, error) {
+	ctx, span := tracing.Start(ctx, "App.ListMessages")
+	msgs, err := t.App.ListMessages(ctx, threadID)
+	tracing.End(span, err)
+	return msgs, err
+}
+
+func (t tracedApp) MarkThreadRead(ctx context.Context, threadID int64, upTo int64) ([]chat.Message, error) {
+	ctx, span := tracing.Start(ctx, "App.MarkThreadRead")
+	msgs, err := t.App.MarkThreadRead(ctx, threadID, upTo)
+	tracing.End(span, err)
+	return msgs, err
+}
+
+func (t tracedApp) ListThreads(ctx context.Context, userID int64) ([]chat.Summary, error) {
+	ctx, span := tracing.Start(ctx, "App.ListThreads")
+	threads, err := t.App.ListThreads(ctx, userID)
+	tracing.End(span, err)
+	return threads, err
+}
+
+func (t tracedApp) Stats(ctx context.Context) (Stats, error) {
+	ctx, span := tracing.Start(ctx, "App.Stats")
+	stats, err := t.App.Stats(ctx)
+	tracing.End(span, err)
+	return stats, err
+}
+
+func (t tracedRepository) Find(ctx context.Context, adID int64) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "Repository.Find")
+	ad, err := t.Repository.Find(ctx, adID)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedRepository) Add(ctx context.Context, title string, text string, attrs ads.Attributes, userID int64) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "Repository.Add")
+	ad, err := t.Repository.Add(ctx, title, text, attrs, userID)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedRepository) Update(ctx context.Context, adID int64, mutate func(ad *ads.Ad) error) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "Repository.Update")
+	ad, err := t.Repository.Update(ctx, adID, mutate)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedRepository) GetByTitle(ctx context.Context, title string, page Page) ([]ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "Repository.GetByTitle")
+	adss, err := t.Repository.GetByTitle(ctx, title, page)
+	tracing.End(span, err)
+	return adss, err
+}
+
+func (t tracedRepository) GetAdsByFilter(ctx context.Context, filter Filter, page Page) ([]ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "Repository.GetAdsByFilter")
+	adss, err := t.Repository.GetAdsByFilter(ctx, filter, page)
+	tracing.End(span, err)
+	return adss, err
+}
+
+func (t tracedRepository) Search(ctx context.Context, query string, filter Filter, limit int) ([]SearchResult, error) {
+	ctx, span := tracing.Start(ctx, "Repository.Search")
+	results, err := t.Repository.Search(ctx, query, filter, limit)
+	tracing.End(span, err)
+	return results, err
+}
+
+func (t tracedRepository) Delete(ctx context.Context, adID int64, check func(ad ads.Ad) error) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "Repository.Delete")
+	ad, err := t.Repository.Delete(ctx, adID, check)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedRepository) Restore(ctx context.Context, adID int64, check func(ad ads.Ad) error) (ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "Repository.Restore")
+	ad, err := t.Repository.Restore(ctx, adID, check)
+	tracing.End(span, err)
+	return ad, err
+}
+
+func (t tracedRepository) DeleteByAuthor(ctx context.Context, authorID int64, at time.Time) ([]ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "Repository.DeleteByAuthor")
+	adss, err := t.Repository.DeleteByAuthor(ctx, authorID, at)
+	tracing.End(span, err)
+	return adss, err
+}
+
+func (t tracedRepository) RestoreByAuthor(ctx context.Context, authorID int64, at time.Time) ([]ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "Repository.RestoreByAuthor")
+	adss, err := t.Repository.RestoreByAuthor(ctx, authorID, at)
+	tracing.End(span, err)
+	return adss, err
+}
+
+func (t tracedRepository) Purge(ctx context.Context, before time.Time) ([]ads.Ad, error) {
+	ctx, span := tracing.Start(ctx, "Repository.Purge")
+	adss, err := t.Repository.Purge(ctx, before)
+	tracing.End(span, err)
+	return adss, err
+}
+
+func (t tracedRepository) Revisions(ctx context.Context, adID int64) ([]ads.Revision, error) {
+	ctx, span := tracing.Start(ctx, "Repository.Revisions")
+	revs, err := t.Repository.Revisions(ctx, adID)
+	tracing.End(span, err)
+	return revs, err
+}
+
+func (t tracedRepository) Revision(ctx context.Context, adID int64, number int64) (ads.Revision, error) {
+	ctx, span := tracing.Start(ctx, "Repository.Revision")
+	rev, err := t.Repository.Revision(ctx, adID, number)
+	tracing.End(span, err)
+	return rev, err
+}
+
+func (t tracedRepository) CountByStatus(ctx context.Context) (map[ads.Status]int64, error) {
+	ctx, span := tracing.Start(ctx, "Repository.CountByStatus")
+	counts, err := t.Repository.CountByStatus(ctx)
+	tracing.End(span, err)
+	return counts, err
+}
+
+func (t tracedUsers) Get(ctx context.Context, userID int64) (user.User, error) {
+	ctx, span := tracing.Start(ctx, "Users.Get")
+	u, err := t.Users.Get(ctx, userID)
+	tracing.End(span, err)
+	return u, err
+}
+
+func (t tracedUsers) Create(ctx context.Context, nickname, email, passwordHash string, userID int64) (user.User, error) {
+	ctx, span := tracing.Start(ctx, "Users.Create")
+	u, err := t.Users.Create(ctx, nickname, email, passwordHash, userID)
+	tracing.End(span, err)
+	return u, err
+}
+
+func (t tracedUsers) ChangeInfo(ctx context.Context, userID int64, nickname, email string, profile user.Profile) (user.User, error) {
+	ctx, span := tracing.Start(ctx, "Users.ChangeInfo")
+	u, err := t.Users.ChangeInfo(ctx, userID, nickname, email, profile)
+	tracing.End(span, err)
+	return u, err
+}
+
+func (t tracedUsers) DeleteByID(ctx context.Context, userID int64) (user.User, error) {
+	ctx, span := tracing.Start(ctx, "Users.DeleteByID")
+	u, err := t.Users.DeleteByID(ctx, userID)
+	tracing.End(span, err)
+	return u, err
+}
+
+func (t tracedUsers) Restore(ctx context.Context, userID int64, check func(u user.User) error) (user.User, error) {
+	ctx, span := tracing.Start(ctx, "Users.Restore")
+	u, err := t.Users.Restore(ctx, userID, check)
+	tracing.End(span, err)
+	return u, err
+}
+
+func (t tracedUsers) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := tracing.Start(ctx, "Users.Purge")
+	n, err := t.Users.Purge(ctx, before)
+	tracing.End(span, err)
+	return n, err
+}
+
+func (t tracedUsers) PasswordHash(ctx context.Context, userID int64) (string, error) {
+	ctx, span := tracing.Start(ctx, "Users.PasswordHash")
+	hash, err := t.Users.PasswordHash(ctx, userID)
+	tracing.End(span, err)
+	return hash, err
+}
+
+func (t tracedUsers) Count(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "Users.Count")
+	n, err := t.Users.Count(ctx)
+	tracing.End(span, err)
+	return n, err
+}
+
+func (t tracedFavorites) Add(ctx context.Context, userID, adID int64) error {
+	ctx, span := tracing.Start(ctx, "Favorites.Add")
+	err := t.Favorites.Add(ctx, userID, adID)
+	tracing.End(span, err)
+	return err
+}
+
+func (t tracedFavorites) Remove(ctx context.Context, userID, adID int64) error {
+	ctx, span := tracing.Start(ctx, "Favorites.Remove")
+	err := t.Favorites.Remove(ctx, userID, adID)
+	tracing.End(span, err)
+	return err
+}
+
+func (t tracedFavorites) List(ctx context.Context, userID int64) ([]int64, error) {
+	ctx, span := tracing.Start(ctx, "Favorites.List")
+	ids, err := t.Favorites.List(ctx, userID)
+	tracing.End(span, err)
+	return ids, err
+}
+
+func (t tracedFavorites) RemoveAd(ctx context.Context, adID int64) error {
+	ctx, span := tracing.Start(ctx, "Favorites.RemoveAd")
+	err := t.Favorites.RemoveAd(ctx, adID)
+	tracing.End(span, err)
+	return err
+}
+
+func (t tracedChats) OpenThread(ctx context.Context, adID, buyerID, sellerID int64) (chat.Thread, error) {
+	ctx, span := tracing.Start(ctx, "Chats.OpenThread")
+	thread, err := t.Chats.OpenThread(ctx, adID, buyerID, sellerID)
+	tracing.End(span, err)
+	return thread, err
+}
+
+func (t tracedChats) Thread(ctx context.Context, threadID int64) (chat.Thread, error) {
+	ctx, span := tracing.Start(ctx, "Chats.Thread")
+	thread, err := t.Chats.Thread(ctx, threadID)
+	tracing.End(span, err)
+	return thread, err
+}
+
+func (t tracedChats) AddMessage(ctx context.Context, threadID, senderID int64, text string) (chat.Message, error) {
+	ctx, span := tracing.Start(ctx, "Chats.AddMessage")
+	msg, err := t.Chats.AddMessage(ctx, threadID, senderID, text)
+	tracing.End(span, err)
+	return msg, err
+}
+
+func (t tracedChats) Messages(ctx context.Context, threadID int64) ([]chat.Message, error) {
+	ctx, span := tracing.Start(ctx, "Chats.Messages")
+	msgs, err := t.Chats.Messages(ctx, threadID)
+	tracing.End(span, err)
+	return msgs, err
+}
+
+func (t tracedChats) MarkRead(ctx context.Context, threadID, readerID, upTo int64, at time.Time) ([]chat.Message, error) {
+	ctx, span := tracing.Start(ctx, "Chats.MarkRead")
+	msgs, err := t.Chats.MarkRead(ctx, threadID, readerID, upTo, at)
+	tracing.End(span, err)
+	return msgs, err
+}
+
+func (t tracedChats) Inbox(ctx context.Context, userID int64) ([]chat.Summary, error) {
+	ctx, span := tracing.Start(ctx, "Chats.Inbox")
+	threads, err := t.Chats.Inbox(ctx, userID)
+	tracing.End(span, err)
+	return threads, err
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"homework10/internal/ads"
+	"homework10/internal/user"
+)
+
+// Purged - сколько записей окончательно удалила очистка.
+type Purged struct {
+	Ads   int64
+	Users int64
+}
+
+func (s StApp) RestoreAd(ctx context.Context, adID int64) (ads.Ad, error) {
+	if err := s.admin(ctx); err != nil {
+		return ads.Ad{}, err
+	}
+	ad, err := s.repository.Restore(ctx, adID, func(ad ads.Ad) error {
+		_, err := s.users.Get(ctx, ad.AuthorID)
+		if errors.Is(err, ErrNotFound) {
+			return ErrAuthorDeleted
+		}
+		return err
+	})
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	s.publish(ctx, restoreEvent(ad), ad)
+	return ad, nil
+}
+
+func (s StApp) RestoreUser(ctx context.Context, userID int64) (user.User, error) {
+	if err := s.admin(ctx); err != nil {
+		return user.User{}, err
+	}
+	var deletedAt time.Time
+	u, err := s.users.Restore(ctx, userID, func(u user.User) error {
+		deletedAt = *u.DeletedAt
+		return nil
+	})
+	if err != nil {
+		return user.User{}, err
+	}
+	// Объявления, удалённые автором раньше, остаются удалёнными
+	adss, err := s.repository.RestoreByAuthor(ctx, userID, deletedAt)
+	if err != nil {
+		return user.User{}, err
+	}
+	for _, ad := range adss {
+		s.publish(ctx, restoreEvent(ad), ad)
+	}
+	return u, nil
+}
+
+// restoreEvent: восстановленное опубликованное объявление снова появляется в ленте.
+func restoreEvent(ad ads.Ad) EventType {
+	if ad.Published {
+		return EventPublished
+	}
+	return EventUpdated
+}
+
+func (s StApp) ListDeletedAds(ctx context.Context, p Pagination) (AdsPage, error) {
+	if err := s.admin(ctx); err != nil {
+		return AdsPage{}, err
+	}
+	return s.GetAllAdsByFilter(ctx, NewFilter(OnlyDeleted()), p)
+}
+
+// PurgeDeleted сначала очищает объявления: объявления удалённого пользователя удалены
+// не позже него самого и не переживают его. Вместе с объявлением удаляются его изображения.
+func (s StApp) PurgeDeleted(ctx context.Context, before time.Time) (Purged, error) {
+	var res Purged
+	adss, err := s.repository.Purge(ctx, before)
+	if err != nil {
+		return res, err
+	}
+	res.Ads = int64(len(adss))
+	for _, ad := range adss {
+		for _, img := range ad.Images {
+			s.deleteBlobs(ctx, img)
+		}
+	}
+	res.Users, err = s.users.Purge(ctx, before)
+	return res, err
+}
+
+// RunPurge каждые interval окончательно удаляет записи, пролежавшие удалёнными дольше
+// retention, пока не будет отменён ctx. Результат каждого прохода передаётся в report.
+func RunPurge(ctx context.Context, a App, interval, retention time.Duration, report func(Purged, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			report(a.PurgeDeleted(ctx, now.UTC().Add(-retention)))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package chat
+
+import "time"
+
+// Thread - переписка покупателя с автором объявления. У покупателя по каждому
+// объявлению одна переписка; читать и писать в неё могут только два участника.
+type Thread struct {
+	ID       int64
+	AdID     int64
+	BuyerID  int64
+	SellerID int64
+	// UpdateDate - время последнего сообщения
+	CreationDate time.Time
+	UpdateDate   time.Time
+}
+
+// Participant сообщает, участвует ли пользователь в переписке.
+func (t Thread) Participant(userID int64) bool {
+	return userID == t.BuyerID || userID == t.SellerID
+}
+
+// Peer возвращает второго участника переписки.
+func (t Thread) Peer(userID int64) int64 {
+	if userID == t.BuyerID {
+		return t.SellerID
+	}
+	return t.BuyerID
+}
+
+type Message struct {
+	ID       int64
+	ThreadID int64
+	SenderID int64
+	Text     string `validate:"range:1,999"`
+	// ReadAt задаётся, когда получатель отметил сообщение прочитанным
+	ReadAt       *time.Time
+	CreationDate time.Time
+}
+
+// Summary - переписка во входящих пользователя: последнее сообщение и число
+// непрочитанных им сообщений.
+type Summary struct {
+	Thread
+	LastMessage Message
+	Unread      int64
+}
@@ -0,0 +1,391 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	StorageMemory   = "memory"
+	StoragePostgres = "postgres"
+)
+
+// minSecretLength - наименьшая длина секрета токенов: HS256 требует ключ не короче 256 бит.
+const minSecretLength = 32
+
+// redacted заменяет секреты в выводе --print-config.
+const redacted = "******"
+
+type Config struct {
+	Server  Server  `yaml:"server"`
+	Storage Storage `yaml:"storage"`
+	Auth    Auth    `yaml:"auth"`
+	Purge   Purge   `yaml:"purge"`
+	Log     Log     `yaml:"log"`
+	Tracing Tracing `yaml:"tracing"`
+}
+
+type Server struct {
+	GRPCAddr string `yaml:"grpc_addr"`
+	HTTPAddr string `yaml:"http_addr"`
+	// AdminAddr - адрес служебного сервера с метриками и проверками состояния, всегда без TLS
+	AdminAddr string `yaml:"admin_addr"`
+	// ShutdownTimeout - сколько при остановке ждать завершения текущих запросов
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// ShutdownDelay - сколько перед остановкой обслуживать запросы, сообщая о неготовности
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+	TLS           TLS           `yaml:"tls"`
+}
+
+// TLS включается для HTTP и gRPC серверов, если заданы оба файла в формате PEM.
+type TLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+type Storage struct {
+	Backend     string `yaml:"backend"`
+	PostgresDSN string `yaml:"postgres_dsn"`
+	BlobDir     string `yaml:"blob_dir"`
+}
+
+type Auth struct {
+	// Secret подписывает токены; без него при каждом запуске генерируется случайный
+	Secret      string        `yaml:"secret"`
+	TokenTTL    time.Duration `yaml:"token_ttl"`
+	Moderators  []int64       `yaml:"moderators"`
+	Admins      []int64       `yaml:"admins"`
+	ImportUsers bool          `yaml:"import_users"`
+}
+
+type Purge struct {
+	Interval  time.Duration `yaml:"interval"`
+	Retention time.Duration `yaml:"retention"`
+}
+
+type Log struct {
+	Level string `yaml:"level"`
+}
+
+type Tracing struct {
+	// OTLPEndpoint - host:port коллектора OTLP/gRPC; без него трассировка выключена
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+}
+
+func Default() Config {
+	return Config{
+		Server: Server{
+			GRPCAddr:        ":8080",
+			HTTPAddr:        ":18080",
+			AdminAddr:       ":9090",
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Storage: Storage{Backend: StorageMemory, BlobDir: "blobs"},
+		Auth:    Auth{TokenTTL: 24 * time.Hour},
+		Purge:   Purge{Interval: time.Hour, Retention: 720 * time.Hour},
+		Log:     Log{Level: "info"},
+	}
+}
+
+// setting связывает поле конфигурации с флагом и переменной окружения.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	set   func(s string) error
+	// isBool разрешает указывать флаг без значения, как -import-users
+	isBool bool
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{flag: "grpc-addr", env: "ADS_GRPC_ADDR", usage: "listen address of the gRPC server", set: stringVar(&c.Server.GRPCAddr)},
+		{flag: "http-addr", env: "ADS_HTTP_ADDR", usage: "listen address of the HTTP server", set: stringVar(&c.Server.HTTPAddr)},
+		{flag: "admin-addr", env: "ADS_ADMIN_ADDR", usage: "listen address of the admin server with metrics and health checks", set: stringVar(&c.Server.AdminAddr)},
+		{flag: "shutdown-timeout", env: "ADS_SHUTDOWN_TIMEOUT", usage: "how long servers wait for running requests on shutdown", set: durationVar(&c.Server.ShutdownTimeout)},
+		{flag: "shutdown-delay", env: "ADS_SHUTDOWN_DELAY", usage: "how long servers keep serving as not ready before shutdown, so that load balancers stop sending requests", set: durationVar(&c.Server.ShutdownDelay)},
+		{flag: "tls-cert", env: "ADS_TLS_CERT", usage: "PEM certificate of the HTTP and gRPC servers; TLS is off if empty", set: stringVar(&c.Server.TLS.CertFile)},
+		{flag: "tls-key", env: "ADS_TLS_KEY", usage: "PEM private key for tls-cert", set: stringVar(&c.Server.TLS.KeyFile)},
+		{flag: "storage", env: "ADS_STORAGE", usage: "ads storage backend: memory or postgres", set: stringVar(&c.Storage.Backend)},
+		{flag: "postgres-dsn", env: "ADS_POSTGRES_DSN", usage: "postgres connection string", set: stringVar(&c.Storage.PostgresDSN)},
+		{flag: "blob-dir", env: "ADS_BLOB_DIR", usage: "directory for uploaded ad images", set: stringVar(&c.Storage.BlobDir)},
+		{flag: "auth-secret", env: "ADS_AUTH_SECRET", usage: "secret for signing access tokens, at least 32 characters", set: stringVar(&c.Auth.Secret)},
+		{flag: "token-ttl", env: "ADS_TOKEN_TTL", usage: "lifetime of issued access tokens", set: durationVar(&c.Auth.TokenTTL)},
+		{flag: "moderators", env: "ADS_MODERATORS", usage: "comma-separated IDs of users allowed to publish and reject ads", set: idsVar(&c.Auth.Moderators)},
+		{flag: "admins", env: "ADS_ADMINS", usage: "comma-separated IDs of users allowed to view and restore deleted ads and users", set: idsVar(&c.Auth.Admins)},
+		{flag: "import-users", env: "ADS_IMPORT_USERS", usage: "let any client choose the ID of a registered user, e.g. while importing users", set: boolVar(&c.Auth.ImportUsers), isBool: true},
+		{flag: "purge-interval", env: "ADS_PURGE_INTERVAL", usage: "how often deleted ads and users are purged", set: durationVar(&c.Purge.Interval)},
+		{flag: "retention", env: "ADS_RETENTION", usage: "how long deleted ads and users are kept before purging", set: durationVar(&c.Purge.Retention)},
+		{flag: "log-level", env: "ADS_LOG_LEVEL", usage: "minimal level of log entries: debug, info, warn or error", set: stringVar(&c.Log.Level)},
+		{flag: "otlp-endpoint", env: "ADS_OTLP_ENDPOINT", usage: "host:port of an OTLP/gRPC collector receiving traces; tracing is off if empty", set: stringVar(&c.Tracing.OTLPEndpoint)},
+	}
+}
+
+func stringVar(p *string) func(string) error {
+	return func(s string) error {
+		*p = s
+		return nil
+	}
+}
+
+func durationVar(p *time.Duration) func(string) error {
+	return func(s string) error {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*p = d
+		return nil
+	}
+}
+
+func boolVar(p *bool) func(string) error {
+	return func(s string) error {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		*p = b
+		return nil
+	}
+}
+
+func idsVar(p *[]int64) func(string) error {
+	return func(s string) error {
+		var ids []int64
+		for _, f := range strings.Split(s, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(f, 10, 64)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		*p = ids
+		return nil
+	}
+}
+
+// flagValue откладывает применение флага: флаги важнее файла и окружения, а читаются раньше них.
+type flagValue struct {
+	name   string
+	isBool bool
+	set    *[]flagAssignment
+}
+
+type flagAssignment struct {
+	name, value string
+}
+
+func (v flagValue) String() string {
+	return ""
+}
+
+func (v flagValue) Set(s string) error {
+	*v.set = append(*v.set, flagAssignment{name: v.name, value: s})
+	return nil
+}
+
+func (v flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// Load собирает конфигурацию по слоям: значения по умолчанию, YAML-файл из флага -config
+// или ADS_CONFIG, переменные окружения ADS_*, флаги командной строки из args; каждый
+// следующий слой важнее предыдущего. В значениях из файла подставляются переменные окружения
+// ${VAR}, прочие символы "$" остаются как есть; неизвестные ключи считаются ошибкой.
+// Пустые переменные окружения не учитываются.
+// Флаги регистрируются в fs, так что вызывающий может добавить в fs и свои.
+// Load не проверяет значения: для этого есть Validate.
+func Load(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	var assigned []flagAssignment
+	configFile := fs.String("config", "", "YAML configuration file; env ADS_CONFIG")
+	for _, s := range settings {
+		fs.Var(flagValue{name: s.flag, isBool: s.isBool, set: &assigned}, s.flag, s.usage+"; env "+s.env)
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv("ADS_CONFIG")
+	}
+	if path != "" {
+		if err := cfg.readFile(path, lookupEnv); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := lookupEnv(s.env); ok && v != "" {
+			if err := s.set(v); err != nil {
+				return Config{}, fmt.Errorf("invalid %s: %w", s.env, err)
+			}
+		}
+	}
+
+	bySetting := map[string]setting{}
+	for _, s := range settings {
+		bySetting[s.flag] = s
+	}
+	for _, a := range assigned {
+		if err := bySetting[a.name].set(a.value); err != nil {
+			return Config{}, fmt.Errorf("invalid -%s: %w", a.name, err)
+		}
+	}
+	return cfg, nil
+}
+
+func (c *Config) readFile(path string, lookupEnv func(string) (string, bool)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("can't read config file: %w", err)
+	}
+
+	// Переменные подставляются в уже разобранные значения: иначе "$" в секрете
+	// исказился бы, а значение переменной могло бы поменять структуру файла
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("broken config file %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		return nil
+	}
+	expandEnv(&doc, lookupEnv)
+	if data, err = yaml.Marshal(&doc); err != nil {
+		return fmt.Errorf("broken config file %s: %w", path, err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("broken config file %s: %w", path, err)
+	}
+	return nil
+}
+
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv подставляет переменные ${VAR} в скалярные значения узла; ключи не меняются.
+func expandEnv(n *yaml.Node, lookupEnv func(string) (string, bool)) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		v := envRef.ReplaceAllStringFunc(n.Value, func(ref string) string {
+			v, _ := lookupEnv(envRef.FindStringSubmatch(ref)[1])
+			return v
+		})
+		if v != n.Value {
+			n.Value = v
+			// Тип значения без кавычек определяется заново: ${PORT} может стать числом
+			if n.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+				n.Tag = ""
+			}
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			expandEnv(n.Content[i], lookupEnv)
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, c := range n.Content {
+			expandEnv(c, lookupEnv)
+		}
+	}
+}
+
+// Validate проверяет конфигурацию целиком и перечисляет все найденные ошибки.
+func (c Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	addrs := map[string]string{}
+	for _, a := range []struct{ name, addr string }{
+		{"server.grpc_addr", c.Server.GRPCAddr},
+		{"server.http_addr", c.Server.HTTPAddr},
+		{"server.admin_addr", c.Server.AdminAddr},
+	} {
+		_, _, err := net.SplitHostPort(a.addr)
+		check(err == nil, "%s: invalid address %q", a.name, a.addr)
+		if other, ok := addrs[a.addr]; ok && err == nil {
+			check(false, "%s: address %q is already used by %s", a.name, a.addr, other)
+		}
+		addrs[a.addr] = a.name
+	}
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay must not be negative")
+	check((c.Server.TLS.CertFile == "") == (c.Server.TLS.KeyFile == ""), "server.tls: cert_file and key_file must be set together")
+
+	switch c.Storage.Backend {
+	case StorageMemory:
+	case StoragePostgres:
+		check(c.Storage.PostgresDSN != "", "storage.postgres_dsn is required for postgres storage")
+	default:
+		check(false, "storage.backend: unknown storage %q", c.Storage.Backend)
+	}
+	check(c.Storage.BlobDir != "", "storage.blob_dir is required")
+
+	check(c.Auth.Secret == "" || len(c.Auth.Secret) >= minSecretLength, "auth.secret must be at least %d characters", minSecretLength)
+	check(c.Auth.TokenTTL > 0, "auth.token_ttl must be positive")
+	for _, id := range append(append([]int64(nil), c.Auth.Moderators...), c.Auth.Admins...) {
+		check(id > 0, "auth: invalid user ID %d", id)
+	}
+
+	check(c.Purge.Interval > 0, "purge.interval must be positive")
+	check(c.Purge.Retention >= 0, "purge.retention must not be negative")
+
+	_, err := zapcore.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level: unknown level %q", c.Log.Level)
+
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+var dsnPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
+
+// Redacted возвращает копию конфигурации, которую можно показать: секрет токенов и
+// пароль в строке подключения скрыты.
+func (c Config) Redacted() Config {
+	if c.Auth.Secret != "" {
+		c.Auth.Secret = redacted
+	}
+	if u, err := url.Parse(c.Storage.PostgresDSN); err == nil && u.Scheme != "" {
+		c.Storage.PostgresDSN = u.Redacted()
+	} else {
+		c.Storage.PostgresDSN = dsnPassword.ReplaceAllString(c.Storage.PostgresDSN, "${1}"+redacted)
+	}
+	return c
+}
+
+// YAML выводит конфигурацию в формате файла конфигурации.
+func (c Config) YAML() ([]byte, error) {
+	return yaml.Marshal(c)
+}
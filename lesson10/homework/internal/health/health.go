@@ -0,0 +1,124 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// checkTimeout ограничивает все проверки одного запроса о состоянии.
+const checkTimeout = 2 * time.Second
+
+type check struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Checker отвечает на проверки состояния сервиса по HTTP (/healthz, /readyz) и по
+// протоколу grpc.health.v1. Сервис жив, пока процесс отвечает на запросы, и готов
+// принимать запросы, пока проходят все проверки связи с хранилищами и не начата остановка.
+type Checker struct {
+	mx       sync.RWMutex
+	checks   []check
+	stopping atomic.Bool
+	grpc     *health.Server
+}
+
+func New() *Checker {
+	return &Checker{grpc: health.NewServer()}
+}
+
+// AddCheck добавляет проверку, например связи с базой данных; name попадает в ответ.
+func (c *Checker) AddCheck(name string, fn func(ctx context.Context) error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Shutdown переводит сервис в состояние "не готов" и для HTTP, и для gRPC. Вызывается
+// в начале остановки, пока серверы ещё принимают запросы. Обратно не переключается.
+func (c *Checker) Shutdown() {
+	c.stopping.Store(true)
+	c.grpc.Shutdown()
+}
+
+// run выполняет все проверки и возвращает ошибки проваленных по их именам.
+func (c *Checker) run(ctx context.Context) map[string]string {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	failed := map[string]string{}
+	for _, ch := range c.checks {
+		if err := ch.fn(ctx); err != nil {
+			failed[ch.name] = err.Error()
+		}
+	}
+	return failed
+}
+
+type statusResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Healthz всегда отвечает 200: проверки зависимостей в нём не выполняются, иначе
+// при недоступности базы оркестратор перезапускал бы все экземпляры сервиса разом.
+func (c *Checker) Healthz() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respond(w, statusResponse{Status: "ok"}, http.StatusOK)
+	})
+}
+
+// Readyz отвечает 200, если проходят все проверки, иначе 503 со списком проваленных;
+// после начала остановки - 503 без проверок.
+func (c *Checker) Readyz() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, code := statusResponse{Status: "ok"}, http.StatusOK
+		if c.stopping.Load() {
+			res, code = statusResponse{Status: "shutting down"}, http.StatusServiceUnavailable
+		} else if failed := c.run(r.Context()); len(failed) > 0 {
+			res, code = statusResponse{Status: "unavailable", Checks: failed}, http.StatusServiceUnavailable
+		}
+		respond(w, res, code)
+	})
+}
+
+func respond(w http.ResponseWriter, res statusResponse, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// RegisterGRPC регистрирует на s сервис grpc.health.v1. Сервер в целом (пустое имя)
+// и перечисленные services отвечают SERVING, пока проходят проверки и не начата
+// остановка. Watch сообщает только о начале остановки.
+func (c *Checker) RegisterGRPC(s *grpc.Server, services ...string) {
+	for _, service := range services {
+		c.grpc.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthpb.RegisterHealthServer(s, grpcHealth{Server: c.grpc, checker: c})
+}
+
+type grpcHealth struct {
+	*health.Server
+	checker *Checker
+}
+
+func (h grpcHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	res, err := h.Server.Check(ctx, req)
+	if err != nil || res.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return res, err
+	}
+	if failed := h.checker.run(ctx); len(failed) > 0 {
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return res, nil
+}
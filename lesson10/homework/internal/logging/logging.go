@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RequestIDHeader - заголовок HTTP с ID запроса. В метаданных gRPC тот же ключ
+// записывается в нижнем регистре.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength ограничивает ID, полученный от клиента: он попадает в каждую запись журнала.
+const maxRequestIDLength = 64
+
+// New собирает JSON-логгер, как zap.NewProduction, но с уровнем level (debug, info, warn, error).
+func New(level string) (*zap.Logger, error) {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	cfg := zap.NewProductionConfig()
+	cfg.Level = zap.NewAtomicLevelAt(lvl)
+	cfg.EncoderConfig.TimeKey = "time"
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	return cfg.Build()
+}
+
+type requestIDKey struct{}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает ID запроса из контекста или пустую строку.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDOrNew возвращает присланный клиентом ID запроса, если он подходит, иначе новый.
+// Подходят непустые ID не длиннее 64 символов из букв, цифр и "-", "_", ".".
+func RequestIDOrNew(id string) string {
+	if validRequestID(id) {
+		return id
+	}
+	b := make([]byte, 16)
+	// Ошибка crypto/rand на поддерживаемых системах не возвращается
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// FromContext дополняет logger полем request_id, если ID запроса есть в контексте.
+func FromContext(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	if id := RequestID(ctx); id != "" {
+		return logger.With(zap.String("request_id", id))
+	}
+	return logger
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+const namespace = "ads"
+
+// statsTimeout ограничивает запрос к хранилищу при каждом сборе доменных показателей.
+const statsTimeout = 5 * time.Second
+
+// Metrics собирает показатели сервиса в собственный реестр: несколько серверов
+// в одном процессе, например в тестах, не мешают друг другу.
+type Metrics struct {
+	registry     *prometheus.Registry
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	grpcRequests *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of handled HTTP requests by route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_requests_total",
+			Help:      "Number of handled gRPC calls by method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_request_duration_seconds",
+			Help:      "Latency of gRPC calls by method; for streams - their lifetime.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration, m.grpcRequests, m.grpcDuration,
+	)
+	return m
+}
+
+// RegisterApp добавляет показатели предметной области: число объявлений по состояниям
+// и пользователей. Они считаются заново при каждом сборе.
+func (m *Metrics) RegisterApp(a app.App) {
+	m.registry.MustRegister(&appCollector{app: a})
+}
+
+// Handler отдаёт показатели в текстовом формате Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTP учитывает обработанный запрос; route - шаблон маршрута, а не путь,
+// чтобы число рядов не зависело от ID в запросах.
+func (m *Metrics) ObserveHTTP(method, route string, status int, d time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+func (m *Metrics) ObserveGRPC(method, code string, d time.Duration) {
+	m.grpcRequests.WithLabelValues(method, code).Inc()
+	m.grpcDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+var (
+	adsDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "ad_count"),
+		"Number of not deleted ads by moderation status.", []string{"status"}, nil)
+	usersDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "user_count"),
+		"Number of not deleted users.", nil, nil)
+)
+
+type appCollector struct {
+	app app.App
+}
+
+func (c *appCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- adsDesc
+	ch <- usersDesc
+}
+
+func (c *appCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+	stats, err := c.app.Stats(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(adsDesc, err)
+		ch <- prometheus.NewInvalidMetric(usersDesc, err)
+		return
+	}
+	// Состояния без объявлений отдаются нулями, чтобы ряды не пропадали
+	for _, status := range ads.Statuses {
+		ch <- prometheus.MustNewConstMetric(adsDesc, prometheus.GaugeValue, float64(stats.AdsByStatus[status]), string(status))
+	}
+	ch <- prometheus.MustNewConstMetric(usersDesc, prometheus.GaugeValue, float64(stats.Users))
+}
@@ -0,0 +1,103 @@
+// Package errmap переводит ошибки app в коды ответа HTTP и gRPC, чтобы оба
+// транспорта отвечали на одну и ту же ошибку одинаково.
+package errmap
+
+import (
+	"errors"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/app"
+)
+
+// ErrInternal заменяет в ответе клиенту текст ошибок, не известных errmap: в нём могут
+// оказаться подробности SQL-запросов или пути к файлам.
+var ErrInternal = errors.New("internal error")
+
+type mapping struct {
+	err  error
+	http int
+	grpc codes.Code
+}
+
+// Проверяется по порядку, первое совпадение по errors.Is побеждает.
+var mappings = []mapping{
+	{app.ErrValidation, http.StatusUnprocessableEntity, codes.InvalidArgument},
+	{app.ErrWrongFormat, http.StatusBadRequest, codes.InvalidArgument},
+	{app.ErrNotFound, http.StatusNotFound, codes.NotFound},
+	{app.ErrAlreadyExists, http.StatusConflict, codes.AlreadyExists},
+	{app.ErrAccessDenied, http.StatusForbidden, codes.PermissionDenied},
+	{app.ErrUnauthenticated, http.StatusUnauthorized, codes.Unauthenticated},
+	{app.ErrInvalidTransition, http.StatusConflict, codes.FailedPrecondition},
+	{app.ErrAuthorDeleted, http.StatusConflict, codes.FailedPrecondition},
+	// Версию в HTTP передают только в If-Match, поэтому конфликт версий - невыполненное предусловие
+	{app.ErrVersionConflict, http.StatusPreconditionFailed, codes.Aborted},
+	{app.ErrUnsupportedImage, http.StatusUnsupportedMediaType, codes.InvalidArgument},
+	{app.ErrImageTooLarge, http.StatusRequestEntityTooLarge, codes.InvalidArgument},
+	{app.ErrSlowConsumer, http.StatusServiceUnavailable, codes.ResourceExhausted},
+}
+
+func lookup(err error) (mapping, bool) {
+	for _, m := range mappings {
+		if errors.Is(err, m.err) {
+			return m, true
+		}
+	}
+	return mapping{}, false
+}
+
+// HTTPStatus возвращает код ответа для ошибки; неизвестные ошибки - 500, их текст
+// показывать клиенту нельзя.
+func HTTPStatus(err error) int {
+	if m, ok := lookup(err); ok {
+		return m.http
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCError оборачивает ошибку в статус gRPC. Неизвестные ошибки клиент получает как
+// codes.Internal с текстом ErrInternal, а перехватчики видят исходную ошибку.
+// Для app.ValidationError поля передаются в деталях errdetails.BadRequest.
+func GRPCError(err error) error {
+	m, ok := lookup(err)
+	if !ok {
+		return internalError{err: err}
+	}
+	st := status.New(m.grpc, err.Error())
+
+	var verr *app.ValidationError
+	if errors.As(err, &verr) {
+		br := &errdetails.BadRequest{}
+		for _, f := range verr.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Reason,
+			})
+		}
+		if withDetails, detailsErr := st.WithDetails(br); detailsErr == nil {
+			st = withDetails
+		}
+	}
+	return st.Err()
+}
+
+// internalError отдаёт gRPC статус Internal без подробностей, а в Error - исходный текст
+// для журнала.
+type internalError struct {
+	err error
+}
+
+func (e internalError) Error() string {
+	return e.err.Error()
+}
+
+func (e internalError) Unwrap() error {
+	return e.err
+}
+
+func (e internalError) GRPCStatus() *status.Status {
+	return status.New(codes.Internal, ErrInternal.Error())
+}
@@ -0,0 +1,165 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"homework10/internal/app"
+	"homework10/internal/chat"
+	"homework10/internal/ports/errmap"
+)
+
+// Chat построен так же, как ChatService.Chat из lesson9: горутина читает запросы клиента,
+// а основной цикл пишет в поток. Запросы выполняются сразу; их результат приходит событием
+// подписки, как и сообщения собеседника. Ошибку запроса получает только этот поток,
+// сам поток при этом не завершается.
+func (s AdService) Chat(stream AdService_ChatServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	sub, err := s.a.WatchChats(ctx)
+	if err != nil {
+		return errmap.GRPCError(err)
+	}
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	// Send нельзя вызывать из нескольких горутин, поэтому ошибки запросов идут через канал
+	failures := make(chan *ChatEvent)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			if err := s.chatAction(ctx, req); err != nil {
+				st := status.Convert(errmap.GRPCError(err))
+				select {
+				case failures <- &ChatEvent{
+					Type:      ChatEventType_CHAT_EVENT_TYPE_ERROR,
+					RequestId: req.RequestId,
+					Code:      int32(st.Code()),
+					Error:     st.Message(),
+				}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				if ctx.Err() != nil {
+					return status.FromContextError(ctx.Err()).Err()
+				}
+				return errmap.GRPCError(sub.Err())
+			}
+			if err := stream.Send(newChatEvent(event)); err != nil {
+				return err
+			}
+		case event := <-failures:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case err := <-recvErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (s AdService) chatAction(ctx context.Context, req *ChatRequest) error {
+	var err error
+	switch action := req.Action.(type) {
+	case *ChatRequest_ContactSeller:
+		_, _, err = s.a.ContactSeller(ctx, action.ContactSeller.AdId, action.ContactSeller.Text)
+	case *ChatRequest_SendMessage:
+		_, err = s.a.SendMessage(ctx, action.SendMessage.ThreadId, action.SendMessage.Text)
+	case *ChatRequest_MarkRead:
+		_, err = s.a.MarkThreadRead(ctx, action.MarkRead.ThreadId, action.MarkRead.UpTo)
+	default:
+		err = app.ErrWrongFormat
+	}
+	return err
+}
+
+func (s AdService) ListThreads(ctx context.Context, req *ListThreadsRequest) (*ListThreadsResponse, error) {
+	summaries, err := s.a.ListThreads(ctx, req.UserId)
+	if err != nil {
+		return &ListThreadsResponse{}, errmap.GRPCError(err)
+	}
+	res := &ListThreadsResponse{}
+	for _, summary := range summaries {
+		res.Threads = append(res.Threads, &ThreadSummary{
+			Thread:      newThread(summary.Thread),
+			LastMessage: newChatMessage(summary.LastMessage),
+			Unread:      summary.Unread,
+		})
+	}
+	return res, nil
+}
+
+func (s AdService) ListMessages(ctx context.Context, req *ListMessagesRequest) (*ListMessagesResponse, error) {
+	msgs, err := s.a.ListMessages(ctx, req.ThreadId)
+	if err != nil {
+		return &ListMessagesResponse{}, errmap.GRPCError(err)
+	}
+	return &ListMessagesResponse{Messages: newChatMessages(msgs)}, nil
+}
+
+var chatEventTypes = map[app.ChatEventType]ChatEventType{
+	app.ChatEventMessage: ChatEventType_CHAT_EVENT_TYPE_MESSAGE,
+	app.ChatEventRead:    ChatEventType_CHAT_EVENT_TYPE_READ,
+}
+
+func newChatEvent(event app.ChatEvent) *ChatEvent {
+	return &ChatEvent{
+		Type:     chatEventTypes[event.Type],
+		Thread:   newThread(event.Thread),
+		Messages: newChatMessages(event.Messages),
+		Time:     timestamppb.New(event.Time),
+	}
+}
+
+func newThread(t chat.Thread) *Thread {
+	return &Thread{
+		Id:           t.ID,
+		AdId:         t.AdID,
+		BuyerId:      t.BuyerID,
+		SellerId:     t.SellerID,
+		CreationDate: timestamppb.New(t.CreationDate),
+		UpdateDate:   timestamppb.New(t.UpdateDate),
+	}
+}
+
+func newChatMessage(msg chat.Message) *ChatMessage {
+	return &ChatMessage{
+		Id:           msg.ID,
+		ThreadId:     msg.ThreadID,
+		SenderId:     msg.SenderID,
+		Text:         msg.Text,
+		ReadAt:       asTimestamp(msg.ReadAt),
+		CreationDate: timestamppb.New(msg.CreationDate),
+	}
+}
+
+func newChatMessages(msgs []chat.Message) []*ChatMessage {
+	var res []*ChatMessage
+	for _, msg := range msgs {
+		res = append(res, newChatMessage(msg))
+	}
+	return res
+}
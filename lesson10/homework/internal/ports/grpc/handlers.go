@@ -2,134 +2,433 @@ package grpc
 
 import (
 	"context"
-	"errors"
-	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/ads"
 	"homework10/internal/app"
+	"homework10/internal/auth"
+	"homework10/internal/ports/errmap"
+	"homework10/internal/user"
+	"time"
 )
 
 type AdService struct {
-	a app.App
+	a      app.App
+	tokens *auth.Tokens
 }
 
-func NewService(a app.App) AdService {
-	return AdService{a}
+func NewService(a app.App, tokens *auth.Tokens) AdService {
+	return AdService{a: a, tokens: tokens}
 }
 
 func (s AdService) CreateAd(ctx context.Context, req *CreateAdRequest) (*AdResponse, error) {
-	ad, err := s.a.CreateAd(ctx, req.Title, req.Text, req.UserId)
+	ad, err := s.a.CreateAd(ctx, req.Title, req.Text, attributesFromRequest(req.Attributes))
 	if err != nil {
-		if errors.Is(err, app.ErrWrongFormat) {
-			return &AdResponse{}, status.Error(codes.InvalidArgument, err.Error())
-		}
-		return &AdResponse{}, status.Error(codes.Internal, err.Error())
+		return &AdResponse{}, errmap.GRPCError(err)
 	}
-	return &AdResponse{Id: ad.ID,
-		Title:        ad.Title,
-		Text:         ad.Text,
-		AuthorId:     ad.AuthorID,
-		Published:    ad.Published,
-		CreationDate: timestamppb.New(ad.CreationDate),
-		UpdateDate:   timestamppb.New(ad.CreationDate)}, nil
+	return newAdResponse(ad), nil
 }
 
 func (s AdService) ChangeAdStatus(ctx context.Context, req *ChangeAdStatusRequest) (*AdResponse, error) {
-	ad, err := s.a.ChangeAdStatus(ctx, req.AdId, req.UserId, req.Published)
+	ad, err := s.a.ChangeAdStatus(ctx, req.AdId, adStatuses[req.Status], req.Reason, app.AnyVersion)
 	if err != nil {
-		if errors.Is(err, app.ErrAccessDenied) {
-			return &AdResponse{}, status.Error(codes.PermissionDenied, err.Error())
-		}
-		if errors.Is(err, app.ErrWrongFormat) {
-			return &AdResponse{}, status.Error(codes.InvalidArgument, err.Error())
-		}
-		return &AdResponse{}, status.Error(codes.Internal, err.Error())
+		return &AdResponse{}, errmap.GRPCError(err)
 	}
-	return &AdResponse{Id: ad.ID,
-		Title:        ad.Title,
-		Text:         ad.Text,
-		AuthorId:     ad.AuthorID,
-		Published:    ad.Published,
-		CreationDate: timestamppb.New(ad.CreationDate),
-		UpdateDate:   timestamppb.New(ad.CreationDate)}, nil
+	return newAdResponse(ad), nil
 }
 
 func (s AdService) UpdateAd(ctx context.Context, req *UpdateAdRequest) (*AdResponse, error) {
-	ad, err := s.a.UpdateAd(ctx, req.AdId, req.UserId, req.Title, req.Text)
+	var attrs *ads.Attributes
+	if req.Attributes != nil {
+		a := attributesFromRequest(req.Attributes)
+		attrs = &a
+	}
+	ad, err := s.a.UpdateAd(ctx, req.AdId, req.Title, req.Text, attrs, req.ExpectedVersion)
 	if err != nil {
-		if errors.Is(err, app.ErrAccessDenied) {
-			return &AdResponse{}, status.Error(codes.PermissionDenied, err.Error())
-		}
-		if errors.Is(err, app.ErrWrongFormat) {
-			return &AdResponse{}, status.Error(codes.InvalidArgument, err.Error())
-		}
-		return &AdResponse{}, status.Error(codes.Internal, err.Error())
+		return &AdResponse{}, errmap.GRPCError(err)
 	}
-	return &AdResponse{Id: ad.ID,
-		Title:        ad.Title,
-		Text:         ad.Text,
-		AuthorId:     ad.AuthorID,
-		Published:    ad.Published,
-		CreationDate: timestamppb.New(ad.CreationDate),
-		UpdateDate:   timestamppb.New(ad.CreationDate)}, nil
+	return newAdResponse(ad), nil
 }
 
 func (s AdService) DeleteAd(ctx context.Context, req *DeleteAdRequest) (*AdResponse, error) {
-	ad, err := s.a.DeleteAd(ctx, req.AdId, req.AuthorId)
+	ad, err := s.a.DeleteAd(ctx, req.AdId, app.AnyVersion)
 	if err != nil {
-		if errors.Is(err, app.ErrAccessDenied) {
-			return &AdResponse{}, status.Error(codes.PermissionDenied, err.Error())
-		}
-		if errors.Is(err, app.ErrWrongFormat) {
-			return &AdResponse{}, status.Error(codes.InvalidArgument, err.Error())
+		return &AdResponse{}, errmap.GRPCError(err)
+	}
+	return newAdResponse(ad), nil
+}
+
+func (s AdService) GetAd(ctx context.Context, req *GetAdRequest) (*AdResponse, error) {
+	ad, err := s.a.GetAd(ctx, req.Id)
+	if err != nil {
+		return &AdResponse{}, errmap.GRPCError(err)
+	}
+	return newAdResponse(ad), nil
+}
+
+func (s AdService) ListAds(ctx context.Context, req *FilterRequest) (*ListAdResponse, error) {
+	p := app.Pagination{
+		Limit:  int(req.PageSize),
+		Cursor: req.PageToken,
+		SortBy: app.SortKey(req.SortBy),
+		Desc:   req.Desc,
+	}
+	page, err := s.a.GetAllAdsByFilter(ctx, filterFromRequest(req), p)
+	if err != nil {
+		return &ListAdResponse{}, errmap.GRPCError(err)
+	}
+	return newListAdResponse(page), nil
+}
+
+func (s AdService) CreateUser(ctx context.Context, req *CreateUserRequest) (*UniversalUser, error) {
+	u, err := s.a.CreateUser(ctx, req.Nickname, req.Email, req.Password, req.UserId)
+	if err != nil {
+		return &UniversalUser{}, errmap.GRPCError(err)
+	}
+	return newUserResponse(u), nil
+}
+
+func (s AdService) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+	u, err := s.a.Authenticate(ctx, req.UserId, req.Password)
+	if err != nil {
+		return &LoginResponse{}, errmap.GRPCError(err)
+	}
+	token, err := s.tokens.Issue(u.ID)
+	if err != nil {
+		return &LoginResponse{}, errmap.GRPCError(err)
+	}
+	return &LoginResponse{Token: token}, nil
+}
+
+func (s AdService) DeleteUserByID(ctx context.Context, req *DeleteUserRequest) (*UniversalUser, error) {
+	u, err := s.a.DeleteUser(ctx, req.Id)
+	if err != nil {
+		return &UniversalUser{}, errmap.GRPCError(err)
+	}
+	return newUserResponse(u), nil
+}
+
+func (s AdService) GetAdsByTitle(ctx context.Context, req *GetAdsByTitleRequest) (*ListAdResponse, error) {
+	p := app.Pagination{
+		Limit:  int(req.PageSize),
+		Cursor: req.PageToken,
+		SortBy: app.SortKey(req.SortBy),
+		Desc:   req.Desc,
+	}
+	page, err := s.a.GetAdsByTitle(ctx, req.Title, p)
+	if err != nil {
+		return &ListAdResponse{}, errmap.GRPCError(err)
+	}
+	return newListAdResponse(page), nil
+}
+
+// GetUser доступен без токена; email в ответе есть только у самого пользователя и
+// администраторов (см. app.GetUser).
+func (s AdService) GetUser(ctx context.Context, req *GetUserRequest) (*UniversalUser, error) {
+	u, err := s.a.GetUser(ctx, req.Id)
+	if err != nil {
+		return &UniversalUser{}, errmap.GRPCError(err)
+	}
+	return newUserResponse(u), nil
+}
+
+func (s AdService) ListUserAds(ctx context.Context, req *ListUserAdsRequest) (*ListAdResponse, error) {
+	p := app.Pagination{
+		Limit:  int(req.PageSize),
+		Cursor: req.PageToken,
+		SortBy: app.SortKey(req.SortBy),
+		Desc:   req.Desc,
+	}
+	page, err := s.a.ListUserAds(ctx, req.UserId, p)
+	if err != nil {
+		return &ListAdResponse{}, errmap.GRPCError(err)
+	}
+	return newListAdResponse(page), nil
+}
+
+func (s AdService) ChangeUserInfo(ctx context.Context, req *ChangeUserInfoRequest) (*UniversalUser, error) {
+	var profile *user.Profile
+	if req.Profile != nil {
+		profile = &user.Profile{DisplayName: req.Profile.DisplayName, AvatarURL: req.Profile.AvatarUrl}
+	}
+	u, err := s.a.ChangeUserInfo(ctx, req.UserId, req.Nickname, req.Email, profile)
+	if err != nil {
+		return &UniversalUser{}, errmap.GRPCError(err)
+	}
+	return newUserResponse(u), nil
+}
+
+func (s AdService) SearchAds(ctx context.Context, req *SearchAdsRequest) (*SearchAdsResponse, error) {
+	results, err := s.a.SearchAds(ctx, req.Query, int(req.Limit))
+	if err != nil {
+		return &SearchAdsResponse{}, errmap.GRPCError(err)
+	}
+
+	res := SearchAdsResponse{}
+	for _, r := range results {
+		res.Results = append(res.Results, &SearchResult{Ad: newAdResponse(r.Ad), Score: r.Score})
+	}
+	return &res, nil
+}
+
+// Метод для подписки на события объявлений, подходящих под фильтр. Заголовки ответа
+// отправляются после оформления подписки: события, случившиеся позже, не потеряются.
+// Клиент, не успевающий читать события, отключается с codes.ResourceExhausted
+func (s AdService) WatchAds(req *FilterRequest, stream AdService_WatchAdsServer) error {
+	ctx := stream.Context()
+	sub := s.a.WatchAds(ctx, filterFromRequest(req))
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for event := range sub.Events() {
+		err := stream.Send(&AdEvent{
+			Type: AdEventType(event.Type),
+			Ad:   newAdResponse(event.Ad),
+			Time: timestamppb.New(event.Time),
+		})
+		if err != nil {
+			return err
 		}
-		return &AdResponse{}, status.Error(codes.Internal, err.Error())
+	}
+
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return errmap.GRPCError(sub.Err())
+}
+
+func (s AdService) ListAdRevisions(ctx context.Context, req *ListAdRevisionsRequest) (*ListAdRevisionsResponse, error) {
+	revs, err := s.a.ListAdRevisions(ctx, req.AdId)
+	if err != nil {
+		return &ListAdRevisionsResponse{}, errmap.GRPCError(err)
+	}
+
+	res := ListAdRevisionsResponse{}
+	for _, rev := range revs {
+		res.Revisions = append(res.Revisions, &AdRevision{
+			AdId:         rev.AdID,
+			Number:       rev.Number,
+			Title:        rev.Title,
+			Text:         rev.Text,
+			EditorId:     rev.EditorID,
+			CreationDate: timestamppb.New(rev.CreationDate),
+		})
+	}
+	return &res, nil
+}
+
+func (s AdService) RestoreAdRevision(ctx context.Context, req *RestoreAdRevisionRequest) (*AdResponse, error) {
+	ad, err := s.a.RestoreAdRevision(ctx, req.AdId, req.Number)
+	if err != nil {
+		return &AdResponse{}, errmap.GRPCError(err)
+	}
+	return newAdResponse(ad), nil
+}
+
+func (s AdService) AddFavorite(ctx context.Context, req *FavoriteRequest) (*AdResponse, error) {
+	ad, err := s.a.AddFavorite(ctx, req.UserId, req.AdId)
+	if err != nil {
+		return &AdResponse{}, errmap.GRPCError(err)
+	}
+	return newAdResponse(ad), nil
+}
+
+func (s AdService) RemoveFavorite(ctx context.Context, req *FavoriteRequest) (*AdResponse, error) {
+	ad, err := s.a.RemoveFavorite(ctx, req.UserId, req.AdId)
+	if err != nil {
+		return &AdResponse{}, errmap.GRPCError(err)
+	}
+	return newAdResponse(ad), nil
+}
+
+func (s AdService) ListFavorites(ctx context.Context, req *ListFavoritesRequest) (*ListAdResponse, error) {
+	adss, err := s.a.ListFavorites(ctx, req.UserId)
+	if err != nil {
+		return &ListAdResponse{}, errmap.GRPCError(err)
+	}
+	return newListAdResponse(app.AdsPage{Ads: adss}), nil
+}
+
+func (s AdService) ListDeletedAds(ctx context.Context, req *ListDeletedAdsRequest) (*ListAdResponse, error) {
+	p := app.Pagination{
+		Limit:  int(req.PageSize),
+		Cursor: req.PageToken,
+		SortBy: app.SortKey(req.SortBy),
+		Desc:   req.Desc,
+	}
+	page, err := s.a.ListDeletedAds(ctx, p)
+	if err != nil {
+		return &ListAdResponse{}, errmap.GRPCError(err)
+	}
+	return newListAdResponse(page), nil
+}
+
+func (s AdService) RestoreAd(ctx context.Context, req *RestoreAdRequest) (*AdResponse, error) {
+	ad, err := s.a.RestoreAd(ctx, req.AdId)
+	if err != nil {
+		return &AdResponse{}, errmap.GRPCError(err)
+	}
+	return newAdResponse(ad), nil
+}
+
+func (s AdService) RestoreUser(ctx context.Context, req *RestoreUserRequest) (*UniversalUser, error) {
+	u, err := s.a.RestoreUser(ctx, req.UserId)
+	if err != nil {
+		return &UniversalUser{}, errmap.GRPCError(err)
+	}
+	return newUserResponse(u), nil
+}
+
+func newAdResponse(ad ads.Ad) *AdResponse {
+	var images []*AdImage
+	for _, img := range ad.Images {
+		images = append(images, &AdImage{
+			Id:           img.ID,
+			Url:          app.ImageURL(ad.ID, img, false),
+			ThumbnailUrl: app.ImageURL(ad.ID, img, true),
+			ContentType:  img.ContentType,
+			Size:         img.Size,
+			Width:        int32(img.Width),
+			Height:       int32(img.Height),
+		})
 	}
 	return &AdResponse{Id: ad.ID,
 		Title:        ad.Title,
 		Text:         ad.Text,
 		AuthorId:     ad.AuthorID,
 		Published:    ad.Published,
+		Status:       protoStatuses[ad.Status],
+		RejectReason: ad.RejectReason,
+		Version:      ad.Version,
 		CreationDate: timestamppb.New(ad.CreationDate),
-		UpdateDate:   timestamppb.New(ad.CreationDate)}, nil
+		UpdateDate:   timestamppb.New(ad.UpdateDate),
+		DeletedAt:    asTimestamp(ad.DeletedAt),
+		Images:       images,
+		Attributes:   newAttributes(ad.Attributes)}
 }
 
-func (s AdService) ListAds(ctx context.Context, req *FilterRequest) (*ListAdResponse, error) {
-	f, _ := s.a.NewFilter(ctx)
+// attributesFromRequest переводит отсутствующие атрибуты в пустые.
+func attributesFromRequest(req *AdAttributes) ads.Attributes {
+	attrs := ads.Attributes{
+		Category: req.GetCategory(),
+		Tags:     req.GetTags(),
+		Location: req.GetLocation(),
+	}
+	if price := req.GetPrice(); price != nil {
+		attrs.Price = ads.Price{Amount: price.Amount, Currency: price.Currency}
+	}
+	return attrs
+}
+
+func newAttributes(attrs ads.Attributes) *AdAttributes {
+	res := &AdAttributes{
+		Category: attrs.Category,
+		Tags:     attrs.Tags,
+		Location: attrs.Location,
+	}
+	if attrs.Price.Amount != 0 {
+		res.Price = &Price{Amount: attrs.Price.Amount, Currency: attrs.Price.Currency}
+	}
+	return res
+}
 
-	adf, _ := f.GetFilter(ctx)
-	ads, _ := s.a.GetAllAdsByFilter(ctx, adf)
+func (s AdService) ListCategories(ctx context.Context, req *ListCategoriesRequest) (*ListCategoriesResponse, error) {
+	return &ListCategoriesResponse{Categories: newCategories(ads.Categories())}, nil
+}
 
-	res := ListAdResponse{}
-	for _, ad := range ads {
-		res.List = append(res.List, &AdResponse{Id: ad.ID,
-			Title:        ad.Title,
-			Text:         ad.Text,
-			AuthorId:     ad.AuthorID,
-			Published:    ad.Published,
-			CreationDate: timestamppb.New(ad.CreationDate),
-			UpdateDate:   timestamppb.New(ad.CreationDate)})
+func newCategories(categories []ads.Category) []*Category {
+	var res []*Category
+	for _, c := range categories {
+		res = append(res, &Category{Id: c.ID, Name: c.Name, Children: newCategories(c.Children)})
 	}
-	return &res, nil
+	return res
 }
 
-func (s AdService) CreateUser(ctx context.Context, req *UniversalUser) (*UniversalUser, error) {
-	u, err := s.a.CreateUser(ctx, req.Nickname, req.Email, req.UserId)
-	if err != nil {
-		if errors.Is(err, app.ErrWrongFormat) {
-			return &UniversalUser{}, status.Error(codes.InvalidArgument, err.Error())
-		}
+// Отсутствующее в adStatuses значение, в том числе AD_STATUS_UNSPECIFIED, переводится в пустое
+// состояние: app отклонит его как неизвестное, а фильтр - не станет проверять состояние.
+var adStatuses = map[AdStatus]ads.Status{
+	AdStatus_AD_STATUS_DRAFT:     ads.StatusDraft,
+	AdStatus_AD_STATUS_PENDING:   ads.StatusPending,
+	AdStatus_AD_STATUS_PUBLISHED: ads.StatusPublished,
+	AdStatus_AD_STATUS_REJECTED:  ads.StatusRejected,
+	AdStatus_AD_STATUS_ARCHIVED:  ads.StatusArchived,
+}
+
+var protoStatuses = map[ads.Status]AdStatus{
+	ads.StatusDraft:     AdStatus_AD_STATUS_DRAFT,
+	ads.StatusPending:   AdStatus_AD_STATUS_PENDING,
+	ads.StatusPublished: AdStatus_AD_STATUS_PUBLISHED,
+	ads.StatusRejected:  AdStatus_AD_STATUS_REJECTED,
+	ads.StatusArchived:  AdStatus_AD_STATUS_ARCHIVED,
+}
+
+func newListAdResponse(page app.AdsPage) *ListAdResponse {
+	res := ListAdResponse{NextPageToken: page.NextCursor}
+	for _, ad := range page.Ads {
+		res.List = append(res.List, newAdResponse(ad))
 	}
-	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email}, nil
+	return &res
 }
 
-func (s AdService) DeleteUserByID(ctx context.Context, req *DeleteUserRequest) (*UniversalUser, error) {
-	u, err := s.a.DeleteUser(ctx, req.Id)
-	if err != nil {
-		if errors.Is(err, app.ErrWrongFormat) {
-			return &UniversalUser{}, status.Error(codes.InvalidArgument, err.Error())
-		}
-		return &UniversalUser{}, status.Error(codes.Internal, err.Error())
+func newUserResponse(u user.User) *UniversalUser {
+	return &UniversalUser{
+		UserId:           u.ID,
+		Nickname:         u.Nickname,
+		Email:            u.Email,
+		DeletedAt:        asTimestamp(u.DeletedAt),
+		DisplayName:      u.DisplayName,
+		AvatarUrl:        u.AvatarURL,
+		RegistrationDate: timestamppb.New(u.RegistrationDate),
+		Rating:           &Rating{Score: u.Rating.Score, Votes: u.Rating.Votes},
+	}
+}
+
+func filterFromRequest(req *FilterRequest) app.Filter {
+	opts := []app.FilterOption{
+		app.CreatedBetween(asTime(req.CreatedFrom), asTime(req.CreatedTo)),
+		app.UpdatedBetween(asTime(req.UpdatedFrom), asTime(req.UpdatedTo)),
+		app.TitleContains(req.Title),
+		app.TextContains(req.Text),
+		app.InCategory(req.Category),
+		app.WithTags(req.Tags...),
+		app.PriceBetween(req.Currency, req.PriceMin, req.PriceMax),
+		app.AtLocation(req.Location),
+	}
+
+	switch req.Published {
+	case PublishedFilter_PUBLISHED_FILTER_UNPUBLISHED:
+		opts = append(opts, app.WithPublished(app.OnlyUnpublished))
+	case PublishedFilter_PUBLISHED_FILTER_ALL:
+		opts = append(opts, app.WithPublished(app.AnyPublished))
+	default:
+		opts = append(opts, app.WithPublished(app.OnlyPublished))
+	}
+
+	if status, ok := adStatuses[req.Status]; ok {
+		opts = append(opts, app.WithStatus(status))
+	}
+
+	if req.AuthorId != nil {
+		opts = append(opts, app.WithAuthor(*req.AuthorId))
+	}
+
+	return app.NewFilter(opts...)
+}
+
+// asTimestamp переводит отсутствующее время в отсутствующую метку.
+func asTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// asTime, в отличие от Timestamp.AsTime, переводит отсутствующую метку в нулевое время.
+func asTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
 	}
-	return &UniversalUser{UserId: u.ID, Nickname: u.Nickname, Email: u.Email}, nil
+	return ts.AsTime()
 }
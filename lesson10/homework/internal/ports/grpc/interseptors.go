@@ -2,30 +2,303 @@ package grpc
 
 import (
 	"context"
-	"fmt"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
-	"log"
+	"homework10/internal/app"
+	"homework10/internal/auth"
+	"homework10/internal/logging"
+	"homework10/internal/metrics"
+	"homework10/internal/ports/errmap"
+	"homework10/internal/tracing"
 	"runtime/debug"
+	"strings"
 	"time"
 )
 
-func UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	timer := time.Now()
+// requestIDMetadata - ключ метаданных с ID запроса, в запросе и в заголовках ответа.
+var requestIDMetadata = strings.ToLower(logging.RequestIDHeader)
 
-	res, err := handler(ctx, req)
+// UnaryInterceptor пишет в журнал запись о каждом вызове: метод, код ответа, длительность
+// и пользователя. ID запроса берётся из метаданных x-request-id или создаётся заново
+// и возвращается клиенту в заголовке ответа x-request-id.
+func UnaryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx, entry := startCall(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, logging.RequestID(ctx)))
+
+		res, err := handler(ctx, req)
+
+		entry.log(ctx, logger, "grpc call", info.FullMethod, start, err)
+		return res, err
+	}
+}
+
+// RecoveryInterceptor превращает панику в ответ Internal. Стек пишется только в журнал:
+// клиенту подробности устройства сервера не показываются.
+func RecoveryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, logger, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func recovered(ctx context.Context, logger *zap.Logger, method string, r interface{}) error {
+	logging.FromContext(ctx, logger).Error("grpc handler panicked",
+		zap.String("method", method),
+		zap.Any("panic", r),
+		zap.ByteString("stack", debug.Stack()),
+	)
+	return status.Error(codes.Internal, errmap.ErrInternal.Error())
+}
+
+// StreamInterceptor - UnaryInterceptor для потоковых методов; запись делается при завершении потока.
+func StreamInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, entry := startCall(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(requestIDMetadata, logging.RequestID(ctx)))
+
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+
+		entry.log(ctx, logger, "grpc stream", info.FullMethod, start, err)
+		return err
+	}
+}
+
+type accessKey struct{}
+
+// accessEntry накапливает сведения о вызове для журнала. Пользователя в неё записывает
+// authorize: контекст с пользователем создаётся глубже и перехватчику журнала не виден.
+type accessEntry struct {
+	userID int64
+	known  bool
+}
 
-	log.Println("method:", info.FullMethod, "timer:", time.Since(timer), "error:", err)
+func startCall(ctx context.Context) (context.Context, *accessEntry) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var id string
+	if values := md.Get(requestIDMetadata); len(values) > 0 {
+		id = values[0]
+	}
+	ctx = logging.WithRequestID(ctx, logging.RequestIDOrNew(id))
+	entry := &accessEntry{}
+	return context.WithValue(ctx, accessKey{}, entry), entry
+}
+
+func noteUser(ctx context.Context, userID int64) {
+	if entry, ok := ctx.Value(accessKey{}).(*accessEntry); ok {
+		entry.userID, entry.known = userID, true
+	}
+}
+
+func (e *accessEntry) log(ctx context.Context, logger *zap.Logger, msg, method string, start time.Time, err error) {
+	code := status.Code(err)
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("code", code.String()),
+		zap.Duration("latency", time.Since(start)),
+	}
+	if e.known {
+		fields = append(fields, zap.Int64("user_id", e.userID))
+	}
+	lvl := zap.InfoLevel
+	if serverError(code) {
+		lvl = zap.ErrorLevel
+		fields = append(fields, zap.Error(err))
+	}
+	logging.FromContext(ctx, logger).Log(lvl, msg, fields...)
+}
+
+// serverError отделяет сбои сервера от ошибок клиента вроде неверных аргументов или отказа в доступе.
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		return true
+	}
+	return false
+}
+
+// TracingInterceptor открывает серверный span вызова, продолжая трассировку клиента из
+// метаданных traceparent. Стоит первым в цепочке, чтобы в span попало всё время вызова.
+func TracingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := startSpan(ctx, info.FullMethod)
+	res, err := handler(ctx, req)
+	endSpan(span, err)
 	return res, err
 }
 
-func RecoveryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ interface{}, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			msg := fmt.Sprintf("Panic: `%s` %s", info.FullMethod, string(debug.Stack()))
-			err = status.Error(codes.Internal, msg)
+func StreamTracingInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startSpan(ss.Context(), info.FullMethod)
+	err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	endSpan(span, err)
+	return err
+}
+
+func startSpan(ctx context.Context, fullMethod string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = tracing.Propagator.Extract(ctx, metadataCarrier(md))
+	// fullMethod имеет вид /пакет.Сервис/Метод
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return tracing.Start(ctx, fullMethod, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		semconv.RPCSystemGRPC,
+		semconv.RPCServiceKey.String(service),
+		semconv.RPCMethodKey.String(method),
+	))
+}
+
+func endSpan(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+	if serverError(code) {
+		span.SetStatus(otelcodes.Error, status.Convert(err).Message())
+	}
+	span.End()
+}
+
+// metadataCarrier даёт Propagator читать контекст трассировки из метаданных запроса.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// MetricsInterceptor учитывает вызов в метриках по его коду ответа. Стоит перед
+// RecoveryInterceptor, чтобы паники попадали в метрики как Internal.
+func MetricsInterceptor(m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		res, err := handler(ctx, req)
+		m.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return res, err
+	}
+}
+
+func StreamMetricsInterceptor(m *metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return err
+	}
+}
+
+func StreamRecoveryInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ss.Context(), logger, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// Методы, доступные без токена
+var publicMethods = map[string]bool{
+	AdService_CreateUser_FullMethodName:      true,
+	AdService_Login_FullMethodName:           true,
+	AdService_ListAds_FullMethodName:         true,
+	AdService_SearchAds_FullMethodName:       true,
+	AdService_GetAd_FullMethodName:           true,
+	AdService_GetUser_FullMethodName:         true,
+	AdService_ListUserAds_FullMethodName:     true,
+	AdService_GetAdsByTitle_FullMethodName:   true,
+	AdService_ListAdRevisions_FullMethodName: true,
+	AdService_ListCategories_FullMethodName:  true,
+	AdService_WatchAds_FullMethodName:        true,
+	// Проверки состояния сервиса (grpc.health.v1) выполняет оркестратор без токена
+	healthpb.Health_Check_FullMethodName: true,
+	healthpb.Health_Watch_FullMethodName: true,
+}
+
+// AuthInterceptor проверяет токен из метаданных "authorization" ("Bearer <token>")
+// и кладёт ID пользователя в контекст вызова.
+func AuthInterceptor(tokens *auth.Tokens) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if publicMethods[info.FullMethod] {
+			// Токен необязателен, но с ним вызов выполняется от имени пользователя,
+			// например регистрация пользователя с заданным ID администратором
+			if userID, err := authorize(ctx, tokens); err == nil {
+				ctx = app.WithUser(ctx, userID)
+			}
+			return handler(ctx, req)
+		}
+
+		userID, err := authorize(ctx, tokens)
+		if err != nil {
+			return nil, err
+		}
+		return handler(app.WithUser(ctx, userID), req)
+	}
+}
+
+// authorize возвращает ID пользователя из метаданных "authorization" ("Bearer <token>").
+func authorize(ctx context.Context, tokens *auth.Tokens) (int64, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return 0, status.Error(codes.Unauthenticated, auth.ErrInvalidToken.Error())
+	}
+	userID, err := tokens.ParseBearer(values[0])
+	if err != nil {
+		return 0, status.Error(codes.Unauthenticated, err.Error())
+	}
+	noteUser(ctx, userID)
+	return userID, nil
+}
+
+// contextStream подменяет контекст потока, например контекстом с ID пользователя.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamAuthInterceptor - AuthInterceptor для потоковых методов.
+func StreamAuthInterceptor(tokens *auth.Tokens) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if publicMethods[info.FullMethod] {
+			ctx := ss.Context()
+			if userID, err := authorize(ctx, tokens); err == nil {
+				ctx = app.WithUser(ctx, userID)
+			}
+			return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		}
+
+		userID, err := authorize(ss.Context(), tokens)
+		if err != nil {
+			return err
 		}
-	}()
-	return handler(ctx, req)
+		return handler(srv, &contextStream{ServerStream: ss, ctx: app.WithUser(ss.Context(), userID)})
+	}
 }
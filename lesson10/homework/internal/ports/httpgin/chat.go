@@ -0,0 +1,134 @@
+package httpgin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+)
+
+// Метод для отправки сообщения автору объявления. Первое сообщение покупателя открывает
+// переписку по объявлению, следующие попадают в неё же; ID переписки - в поле thread_id.
+// Написать по своему объявлению нельзя (403)
+func contactSeller(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody messageRequest
+		err := c.ShouldBindJSON(&reqBody)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		_, msg, err := a.ContactSeller(c, int64(adID), reqBody.Text)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, MessageSuccessResponse(&msg))
+	}
+}
+
+// Метод для отправки сообщения в переписку. Писать и читать переписку могут только покупатель
+// и автор объявления
+func sendMessage(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody messageRequest
+		err := c.ShouldBindJSON(&reqBody)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		threadID, err := strconv.Atoi(c.Param("thread_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		msg, err := a.SendMessage(c, int64(threadID), reqBody.Text)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, MessageSuccessResponse(&msg))
+	}
+}
+
+// Метод для получения сообщений переписки в порядке отправки. У прочитанных получателем
+// сообщений задано время прочтения read_at
+func listMessages(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		threadID, err := strconv.Atoi(c.Param("thread_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		msgs, err := a.ListMessages(c, int64(threadID))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, MessagesSuccessResponse(msgs))
+	}
+}
+
+// Метод для отметки полученных сообщений переписки прочитанными: всех или с ID не больше up_to.
+// Возвращает сообщения, отмеченные этим вызовом
+func markThreadRead(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody markReadRequest
+		// Тело необязательно: без него отмечаются все сообщения
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&reqBody); err != nil {
+				c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+				return
+			}
+		}
+
+		threadID, err := strconv.Atoi(c.Param("thread_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		msgs, err := a.MarkThreadRead(c, int64(threadID), reqBody.UpTo)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, MessagesSuccessResponse(msgs))
+	}
+}
+
+// Метод для получения переписок пользователя с последним сообщением и числом непрочитанных,
+// начиная с переписки с самым новым сообщением. Доступен только самому пользователю
+func listThreads(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		summaries, err := a.ListThreads(c, int64(userID))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, ThreadsSuccessResponse(summaries))
+	}
+}
@@ -0,0 +1,68 @@
+package httpgin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+// etag - сильная метка версии объявления.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func setETag(c *gin.Context, ad ads.Ad) {
+	c.Header("ETag", etag(ad.Version))
+}
+
+// ifMatchVersion возвращает версию объявления adID из заголовка If-Match. Без заголовка и
+// для "*" версия не проверяется. If-Match сравнивает метки строго, поэтому слабая или чужая
+// метка не совпадёт ни с одной версией. Из списка меток выбирается та, что совпадает с
+// текущей версией; если совпадений нет, запрос получит 412.
+func ifMatchVersion(c *gin.Context, a app.App, adID int64) (int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return app.AnyVersion, nil
+	}
+
+	var versions []int64
+	for _, tag := range strings.Split(header, ",") {
+		if version, ok := parseETag(strings.TrimSpace(tag)); ok {
+			versions = append(versions, version)
+		}
+	}
+	switch len(versions) {
+	case 0:
+		return 0, app.ErrVersionConflict
+	case 1:
+		return versions[0], nil
+	}
+
+	// Версию ещё раз проверит app: если объявление успеют изменить, запрос получит 412
+	ad, err := a.GetAd(c, adID)
+	if err != nil {
+		return 0, err
+	}
+	for _, version := range versions {
+		if version == ad.Version {
+			return version, nil
+		}
+	}
+	return 0, app.ErrVersionConflict
+}
+
+// parseETag разбирает сильную метку вида "<версия>".
+func parseETag(tag string) (int64, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
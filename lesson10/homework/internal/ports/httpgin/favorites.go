@@ -0,0 +1,82 @@
+package httpgin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+)
+
+// favoriteParams читает ID пользователя и объявления из пути.
+func favoriteParams(c *gin.Context) (int64, int64, error) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	adID, err := strconv.Atoi(c.Param("ad_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(userID), int64(adID), nil
+}
+
+// Метод для добавления объявления в избранное. Пользователь может менять и просматривать
+// только своё избранное; повторное добавление ошибкой не считается
+func addFavorite(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, adID, err := favoriteParams(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ad, err := a.AddFavorite(c, userID, adID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для удаления объявления из избранного. Если объявления нет в избранном, возвращается 404
+func removeFavorite(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, adID, err := favoriteParams(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ad, err := a.RemoveFavorite(c, userID, adID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для получения избранных объявлений, начиная с добавленного последним.
+// Удалённые объявления из избранного пропадают
+func listFavorites(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		adss, err := a.ListFavorites(c, int64(userID))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, AdSuccessResponseList(&adss))
+	}
+}
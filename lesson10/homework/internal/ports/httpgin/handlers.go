@@ -1,15 +1,43 @@
 package httpgin
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 
+	"homework10/internal/ads"
 	"homework10/internal/app"
+	"homework10/internal/auth"
 )
 
+// Метод для получения токена. Токен передаётся в заголовке Authorization: Bearer <token>
+// во все методы, изменяющие объявления и пользователей
+func login(a app.App, tokens *auth.Tokens) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody loginRequest
+		err := c.ShouldBindJSON(&reqBody)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		u, err := a.Authenticate(c, reqBody.UserID, reqBody.Password)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		token, err := tokens.Issue(u.ID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, LoginSuccessResponse(u.ID, token))
+	}
+}
+
 // Метод для создания объявления (ad)
 func createAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -20,17 +48,20 @@ func createAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, er := a.CreateAd(c, reqBody.Title, reqBody.Text, reqBody.UserID)
-		if errors.Is(er, app.ErrWrongFormat) {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(er))
+		ad, err := a.CreateAd(c, reqBody.Title, reqBody.Text, reqBody.attributes())
+		if err != nil {
+			respondError(c, err)
 			return
 		}
 
+		setETag(c, ad)
 		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
 	}
 }
 
-// Метод для изменения статуса объявления (опубликовано - Published = true или снято с публикации Published = false)
+// Метод для изменения состояния объявления (Status). Автор отправляет объявление на проверку (pending),
+// возвращает в черновик (draft) или снимает в архив (archived); модератор публикует (published)
+// или отклоняет (rejected) с обязательной причиной Reason. Учитывает заголовок If-Match
 func changeAdStatus(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody changeAdStatusRequest
@@ -47,22 +78,27 @@ func changeAdStatus(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, er := a.ChangeAdStatus(c, int64(adID), reqBody.UserID, reqBody.Published)
-		if er != nil {
-			if errors.Is(er, app.ErrWrongFormat) {
-				c.JSON(http.StatusBadRequest, AdErrorResponse(er))
-				return
-			}
-			if errors.Is(er, app.ErrAccessDenied) {
-				c.JSON(http.StatusForbidden, AdErrorResponse(er))
-				return
-			}
+		version, err := ifMatchVersion(c, a, int64(adID))
+		if err != nil {
+			respondError(c, err)
+			return
 		}
+
+		ad, err := a.ChangeAdStatus(c, int64(adID), ads.Status(reqBody.Status), reqBody.Reason, version)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, ad)
 		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
 	}
 }
 
-// Метод для обновления текста(Text) или заголовка(Title) объявления
+// Метод для обновления текста(Text) или заголовка(Title) объявления. Атрибуты (категория, метки,
+// цена, место) заменяются переданными, отсутствующие очищаются. Если в заголовке If-Match
+// передан ETag из прошлого ответа, а объявление с тех пор изменили, изменение не применяется
+// и возвращается 412
 func updateAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody updateAdRequest
@@ -78,35 +114,60 @@ func updateAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, er := a.UpdateAd(c, int64(adID), reqBody.UserID, reqBody.Title, reqBody.Text)
-		if er != nil {
-			if errors.Is(er, app.ErrWrongFormat) {
-				c.JSON(http.StatusBadRequest, AdErrorResponse(er))
-				return
-			}
-			if errors.Is(er, app.ErrAccessDenied) {
-				c.JSON(http.StatusForbidden, AdErrorResponse(er))
-				return
-			}
+		version, err := ifMatchVersion(c, a, int64(adID))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		attrs := reqBody.attributes()
+		ad, err := a.UpdateAd(c, int64(adID), reqBody.Title, reqBody.Text, &attrs, version)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для получения объявления по ID. ETag ответа можно передать в If-Match
+// при следующем изменении объявления
+func getAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ad, err := a.GetAd(c, int64(adID))
+		if err != nil {
+			respondError(c, err)
+			return
 		}
 
+		setETag(c, ad)
 		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
 	}
 }
 
+// Метод для регистрации пользователя. ID выделяет сервер; задать user_id может только
+// администратор (с токеном) или любой клиент, если сервер запущен в режиме импорта.
+// Занятые никнейм или email - 409
 func createUser(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var reqBody universalUser
+		var reqBody createUserRequest
 		err := c.ShouldBindJSON(&reqBody)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
 			return
 		}
 
-		u, er := a.CreateUser(c, reqBody.Nickname, reqBody.Email, reqBody.ID)
-
-		if errors.Is(er, app.ErrWrongFormat) {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(er))
+		u, err := a.CreateUser(c, reqBody.Nickname, reqBody.Email, reqBody.Password, reqBody.ID)
+		if err != nil {
+			respondError(c, err)
 			return
 		}
 
@@ -114,35 +175,53 @@ func createUser(a app.App) gin.HandlerFunc {
 	}
 }
 
+// Метод для получения списка объявлений. Параметры запроса (все необязательные):
+// published=true|false|all, author_id, created_from, created_to, updated_from,
+// updated_to (RFC 3339), title и text - подстроки заголовка и текста, category (вместе
+// с вложенными), tag (можно несколько, нужны все), location, currency с price_min и price_max,
+// а также параметры страницы limit, cursor, sort=id|creation_date|update_date|title
+// и order=asc|desc. Курсор следующей страницы возвращается в поле next_cursor
 func listAds(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		filter, err := a.NewFilter(c)
+		var query listAdsQuery
+		err := c.ShouldBindQuery(&query)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
 			return
 		}
-		strAuthorID := c.Query("author_id")
-		if strAuthorID != "" {
-			authorID, _ := strconv.Atoi(strAuthorID)
-			filter, _ = filter.FilterByAuthor(c, int64(authorID))
+
+		filter, err := query.filter()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
 		}
 
-		f, err := filter.GetFilter(c)
+		p, err := query.pagination()
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
 			return
 		}
 
-		ads, e := a.GetAllAdsByFilter(c, f)
-		if e != nil {
-			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+		page, err := a.GetAllAdsByFilter(c, filter, p)
+		if err != nil {
+			respondError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, AdSuccessResponseList(&ads))
+		c.JSON(http.StatusOK, AdPageSuccessResponse(&page))
 	}
 }
 
+// Метод для получения дерева категорий объявлений. ID категории передаётся в поле category
+// при создании и изменении объявления и в параметре category списка объявлений
+func listCategories() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, CategoriesSuccessResponse(ads.Categories()))
+	}
+}
+
+// Метод для удаления объявления. Объявление пропадает из выдачи, но до очистки его может
+// восстановить администратор. Учитывает заголовок If-Match
 func deleteAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		strAdID := c.Param("ad_id")
@@ -152,27 +231,25 @@ func deleteAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		var reqBody deleteAdRequest
-		err = c.ShouldBindJSON(&reqBody)
+		version, err := ifMatchVersion(c, a, int64(adID))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			respondError(c, err)
 			return
 		}
 
-		ad, er := a.DeleteAd(c, int64(adID), reqBody.UserID)
-		if errors.Is(er, app.ErrWrongFormat) {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(er))
-			return
-		}
-		if errors.Is(er, app.ErrAccessDenied) {
-			c.JSON(http.StatusForbidden, AdErrorResponse(er))
+		ad, err := a.DeleteAd(c, int64(adID), version)
+		if err != nil {
+			respondError(c, err)
 			return
 		}
 
+		setETag(c, ad)
 		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
 	}
 }
 
+// Метод для удаления пользователя вместе со всеми его объявлениями. До очистки
+// администратор может восстановить пользователя, а с ним и эти объявления
 func deleteUser(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		strUserID := c.Param("user_id")
@@ -183,8 +260,8 @@ func deleteUser(a app.App) gin.HandlerFunc {
 		}
 
 		u, err := a.DeleteUser(c, int64(userID))
-		if errors.Is(err, app.ErrWrongFormat) {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+		if err != nil {
+			respondError(c, err)
 			return
 		}
 
@@ -208,30 +285,236 @@ func changeUserInfo(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		u, er := a.ChangeUserInfo(c, int64(userID), reqBody.Nickname, reqBody.Email)
-		if er != nil {
-			if errors.Is(er, app.ErrWrongFormat) {
-				c.JSON(http.StatusBadRequest, AdErrorResponse(er))
-				return
-			}
-			if errors.Is(er, app.ErrAccessDenied) {
-				c.JSON(http.StatusForbidden, AdErrorResponse(er))
-				return
-			}
+		u, err := a.ChangeUserInfo(c, int64(userID), reqBody.Nickname, reqBody.Email, reqBody.profile())
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, UserSuccessResponse(&u))
+	}
+}
+
+// Метод для получения профиля пользователя. Удалённый пользователь не находится (404).
+// Полный профиль с email получают сам пользователь и администраторы, остальные - публичный
+func getUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		u, err := a.GetUser(c, int64(userID))
+		if err != nil {
+			respondError(c, err)
+			return
 		}
 
+		// Email у пользователя обязателен, пустым его оставляет только app.GetUser
+		if u.Email == "" {
+			c.JSON(http.StatusOK, PublicUserResponse(&u))
+			return
+		}
 		c.JSON(http.StatusOK, UserSuccessResponse(&u))
 	}
 }
 
+// Метод для получения опубликованных объявлений пользователя. Параметры страницы те же,
+// что у списка объявлений: limit, cursor, sort и order
+func listUserAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query pageQuery
+		err := c.ShouldBindQuery(&query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		p, err := query.pagination()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		page, err := a.ListUserAds(c, int64(userID), p)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, AdPageSuccessResponse(&page))
+	}
+}
+
 func getAdsByTitle(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		title := c.Query("title")
-		ads, err := a.GetAdsByTitle(c, title)
+		var query adsByTitleQuery
+		err := c.ShouldBindQuery(&query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		p, err := query.pagination()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		page, err := a.GetAdsByTitle(c, query.Title, p)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, AdPageSuccessResponse(&page))
+	}
+}
+
+// Метод для полнотекстового поиска по заголовкам и текстам опубликованных объявлений.
+// Параметры запроса: q - поисковая строка, limit - максимальное число результатов.
+// Результаты упорядочены по убыванию релевантности (поле score)
+func searchAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query searchAdsQuery
+		err := c.ShouldBindQuery(&query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		results, err := a.SearchAds(c, query.Query, query.Limit)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, SearchSuccessResponse(results))
+	}
+}
+
+// Метод для получения истории изменений объявления: версии содержимого (Title, Text)
+// с автором правки и временем, начиная с первоначальной
+func listAdRevisions(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		strAdID := c.Param("ad_id")
+		adID, err := strconv.Atoi(strAdID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		revs, err := a.ListAdRevisions(c, int64(adID))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, RevisionsSuccessResponse(revs))
+	}
+}
+
+// Метод для восстановления содержимого объявления из версии number. Восстановленное
+// содержимое записывается в историю как новая версия
+func restoreAdRevision(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		strAdID := c.Param("ad_id")
+		adID, err := strconv.Atoi(strAdID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		number, err := strconv.ParseInt(c.Param("number"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ad, err := a.RestoreAdRevision(c, int64(adID), number)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для получения удалённых объявлений, ожидающих очистки, для администратора.
+// Параметры страницы те же, что у списка объявлений
+func listDeletedAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query pageQuery
+		err := c.ShouldBindQuery(&query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		p, err := query.pagination()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		page, err := a.ListDeletedAds(c, p)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, AdPageSuccessResponse(&page))
+	}
+}
+
+// Метод для восстановления удалённого объявления администратором. Объявление удалённого
+// автора не восстанавливается (409)
+func restoreAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		strAdID := c.Param("ad_id")
+		adID, err := strconv.Atoi(strAdID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ad, err := a.RestoreAd(c, int64(adID))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			respondError(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, AdSuccessResponseList(&ads))
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для восстановления удалённого пользователя администратором вместе с объявлениями,
+// удалёнными при удалении пользователя
+func restoreUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		strUserID := c.Param("user_id")
+		userID, err := strconv.Atoi(strUserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		u, err := a.RestoreUser(c, int64(userID))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, UserSuccessResponse(&u))
 	}
 }
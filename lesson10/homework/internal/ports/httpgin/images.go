@@ -0,0 +1,80 @@
+package httpgin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+)
+
+// Запас на заголовки multipart сверх самого изображения
+const maxImageRequestSize = app.MaxImageSize + 64<<10
+
+// Метод для загрузки изображения объявления: multipart/form-data с файлом в поле image.
+// Принимаются JPEG, PNG и GIF размером до 5 МБ (иначе 415 и 413), тип определяется
+// по содержимому. В ответе объявление с адресами изображения и его миниатюры
+func uploadAdImage(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		strAdID := c.Param("ad_id")
+		adID, err := strconv.Atoi(strAdID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageRequestSize)
+		file, err := c.FormFile("image")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, AdErrorResponse(app.ErrImageTooLarge))
+				return
+			}
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		defer f.Close()
+
+		ad, err := a.AddAdImage(c, int64(adID), f)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для получения изображения объявления или его миниатюры (всегда JPEG).
+// Содержимое изображения с данным ID не меняется, поэтому его можно кешировать без срока
+func getAdImage(a app.App, thumbnail bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		strAdID := c.Param("ad_id")
+		adID, err := strconv.Atoi(strAdID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		r, contentType, err := a.AdImage(c, int64(adID), c.Param("image_id"), thumbnail)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		defer r.Close()
+
+		c.DataFromReader(http.StatusOK, -1, contentType, r, map[string]string{
+			"Cache-Control":          "public, max-age=31536000, immutable",
+			"X-Content-Type-Options": "nosniff",
+		})
+	}
+}
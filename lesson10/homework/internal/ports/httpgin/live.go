@@ -0,0 +1,205 @@
+package httpgin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+
+	"homework10/internal/app"
+)
+
+const (
+	// liveWriteTimeout ограничивает запись одного сообщения: клиент, который не читает,
+	// перестаёт забирать события из подписки и отключается как медленный.
+	liveWriteTimeout = 10 * time.Second
+	// liveMaxMessageSize - максимальный размер сообщения с фильтром от клиента.
+	liveMaxMessageSize = 4096
+	// closeTryAgainLater - код закрытия 1013 из RFC 6455, которого нет в gobwas/ws.
+	closeTryAgainLater ws.StatusCode = 1013
+)
+
+type liveFilterRequest struct {
+	AuthorID *int64 `json:"author_id"`
+	Keywords string `json:"keywords"`
+}
+
+type liveMessage struct {
+	Type  string      `json:"type"`
+	Ad    *adResponse `json:"ad,omitempty"`
+	Time  *time.Time  `json:"time,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// Метод для получения ленты опубликованных объявлений через WebSocket. После подключения
+// клиент может прислать фильтр {"author_id": 1, "keywords": "..."}; в ответ на каждую
+// (пере)подписку приходит {"type": "subscribed"}, дальше - события объявлений.
+// Клиент, не успевающий читать события, отключается с кодом 1013
+func liveAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conn, _, _, err := ws.UpgradeHTTP(c.Request, c.Writer)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Контекст запроса отменяется при выходе из обработчика, поэтому соединение
+		// обслуживается здесь же, а не в отдельной горутине.
+		newLiveConn(conn).serve(c.Request.Context(), a)
+	}
+}
+
+// liveConn сериализует запись в соединение: события пишет serve, а ответы на
+// управляющие кадры и сообщения об ошибках - читающая горутина.
+type liveConn struct {
+	conn net.Conn
+	mx   sync.Mutex
+}
+
+func newLiveConn(conn net.Conn) *liveConn {
+	return &liveConn{conn: conn}
+}
+
+func (l *liveConn) serve(ctx context.Context, a app.App) {
+	filters := make(chan app.Filter)
+	done := make(chan struct{})
+	defer close(done)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- l.readFilters(filters, done)
+	}()
+
+	// Прежняя подписка отменяется при смене фильтра, последняя - при выходе.
+	cancel := context.CancelFunc(func() {})
+	defer func() { cancel() }()
+	subscribe := func(filter app.Filter) *app.Subscription {
+		cancel()
+		var subCtx context.Context
+		subCtx, cancel = context.WithCancel(ctx)
+		return a.WatchAds(subCtx, filter)
+	}
+
+	sub := subscribe(app.NewFilter())
+	if l.send(liveMessage{Type: "subscribed"}) != nil {
+		return
+	}
+
+	for {
+		select {
+		case filter := <-filters:
+			sub = subscribe(filter)
+			if l.send(liveMessage{Type: "subscribed"}) != nil {
+				return
+			}
+
+		case event, ok := <-sub.Events():
+			if !ok {
+				if errors.Is(sub.Err(), app.ErrSlowConsumer) {
+					l.close(closeTryAgainLater, sub.Err().Error())
+				}
+				return
+			}
+			ad := newAdResponse(event.Ad)
+			if l.send(liveMessage{Type: event.Type.String(), Ad: &ad, Time: &event.Time}) != nil {
+				return
+			}
+
+		case <-readErr:
+			return
+		}
+	}
+}
+
+// readFilters читает фильтры клиента до ошибки чтения или закрытия соединения.
+func (l *liveConn) readFilters(filters chan<- app.Filter, done <-chan struct{}) error {
+	control := wsutil.ControlFrameHandler(l.conn, ws.StateServerSide)
+	rd := &wsutil.Reader{
+		Source:       l.conn,
+		State:        ws.StateServerSide,
+		CheckUTF8:    true,
+		MaxFrameSize: liveMaxMessageSize,
+		OnIntermediate: func(hdr ws.Header, r io.Reader) error {
+			return l.locked(func() error { return control(hdr, r) })
+		},
+	}
+
+	for {
+		hdr, err := rd.NextFrame()
+		if err != nil {
+			return err
+		}
+		if hdr.OpCode.IsControl() {
+			if err := l.locked(func() error { return control(hdr, rd) }); err != nil {
+				return err
+			}
+			continue
+		}
+		if hdr.OpCode&(ws.OpText|ws.OpBinary) == 0 {
+			if err := rd.Discard(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		data, err := io.ReadAll(rd)
+		if err != nil {
+			return err
+		}
+		var req liveFilterRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			if err := l.send(liveMessage{Type: "error", Error: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		select {
+		case filters <- req.filter():
+		case <-done:
+			return nil
+		}
+	}
+}
+
+func (r liveFilterRequest) filter() app.Filter {
+	opts := []app.FilterOption{app.WithKeywords(r.Keywords)}
+	if r.AuthorID != nil {
+		opts = append(opts, app.WithAuthor(*r.AuthorID))
+	}
+	return app.NewFilter(opts...)
+}
+
+func (l *liveConn) send(msg liveMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return l.locked(func() error {
+		if err := l.conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout)); err != nil {
+			return err
+		}
+		return wsutil.WriteServerMessage(l.conn, ws.OpText, data)
+	})
+}
+
+func (l *liveConn) close(code ws.StatusCode, reason string) {
+	_ = l.locked(func() error {
+		if err := l.conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout)); err != nil {
+			return err
+		}
+		return ws.WriteFrame(l.conn, ws.NewCloseFrame(ws.NewCloseFrameBody(code, reason)))
+	})
+}
+
+func (l *liveConn) locked(f func() error) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return f()
+}
@@ -0,0 +1,127 @@
+package httpgin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"homework10/internal/app"
+	"homework10/internal/auth"
+	"homework10/internal/logging"
+	"homework10/internal/metrics"
+	"homework10/internal/tracing"
+)
+
+// requestID берёт ID запроса из заголовка X-Request-ID или создаёт новый, кладёт его
+// в контекст запроса и возвращает клиенту в том же заголовке.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := logging.RequestIDOrNew(c.GetHeader(logging.RequestIDHeader))
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(logging.RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// traceRequest открывает серверный span запроса, продолжая трассировку клиента из
+// заголовка traceparent, если он есть. Ошибкой span отмечается только при ответе 5xx.
+func traceRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := tracing.Propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		name := c.Request.Method + " " + route
+		if route == "" {
+			name = c.Request.Method + " unmatched"
+		}
+		ctx, span := tracing.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			semconv.HTTPMethodKey.String(c.Request.Method),
+			semconv.HTTPRouteKey.String(route),
+		))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// accessLog пишет запись о каждом запросе после его обработки. Пользователь известен,
+// если запрос прошёл аутентификацию.
+func accessLog(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		ctx := c.Request.Context()
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("route", c.FullPath()),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+		}
+		if userID, ok := app.UserFromContext(ctx); ok {
+			fields = append(fields, zap.Int64("user_id", userID))
+		}
+		lvl := zap.InfoLevel
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			lvl = zap.ErrorLevel
+		}
+		if err := c.Errors.Last(); err != nil {
+			fields = append(fields, zap.Error(err.Err))
+		}
+		logging.FromContext(ctx, logger).Log(lvl, "http request", fields...)
+	}
+}
+
+// observe учитывает каждый запрос в метриках. Запросы к несуществующим маршрутам
+// учитываются под общим маршрутом unmatched.
+func observe(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// authRequired пропускает только запросы с действительным токеном в заголовке
+// Authorization и кладёт ID пользователя в контекст запроса.
+func authRequired(tokens *auth.Tokens) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := tokens.ParseBearer(c.GetHeader("Authorization"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, AdErrorResponse(err))
+			return
+		}
+		c.Request = c.Request.WithContext(app.WithUser(c.Request.Context(), userID))
+		c.Next()
+	}
+}
+
+// authOptional, в отличие от authRequired, пропускает и запросы без действительного
+// токена: они выполняются анонимно.
+func authOptional(tokens *auth.Tokens) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID, err := tokens.ParseBearer(c.GetHeader("Authorization")); err == nil {
+			c.Request = c.Request.WithContext(app.WithUser(c.Request.Context(), userID))
+		}
+		c.Next()
+	}
+}
@@ -1,63 +1,219 @@
 package httpgin
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/chat"
+	"homework10/internal/ports/errmap"
 	"homework10/internal/user"
 )
 
+// Пустые заголовок и текст отклоняет проверка в app вместе с остальными ошибками содержимого.
 type createAdRequest struct {
-	Title  string `json:"title" binding:"required"`
-	Text   string `json:"text" binding:"required"`
-	UserID int64  `json:"user_id" binding:"required"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	adAttributes
 }
 
-type universalUser struct {
+// adAttributes - необязательные атрибуты объявления в запросах и ответах.
+// Цена передаётся в минимальных единицах валюты (копейках, центах).
+type adAttributes struct {
+	Category string     `json:"category"`
+	Tags     []string   `json:"tags"`
+	Price    *priceBody `json:"price"`
+	Location string     `json:"location"`
+}
+
+type priceBody struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (r adAttributes) attributes() ads.Attributes {
+	attrs := ads.Attributes{
+		Category: r.Category,
+		Tags:     r.Tags,
+		Location: r.Location,
+	}
+	if r.Price != nil {
+		attrs.Price = ads.Price{Amount: r.Price.Amount, Currency: r.Price.Currency}
+	}
+	return attrs
+}
+
+func newAdAttributes(attrs ads.Attributes) adAttributes {
+	res := adAttributes{
+		Category: attrs.Category,
+		Tags:     attrs.Tags,
+		Location: attrs.Location,
+	}
+	if res.Tags == nil {
+		res.Tags = []string{}
+	}
+	if attrs.Price.Amount != 0 {
+		res.Price = &priceBody{Amount: attrs.Price.Amount, Currency: attrs.Price.Currency}
+	}
+	return res
+}
+
+type createUserRequest struct {
 	Nickname string `json:"nickname" binding:"required"`
 	Email    string `json:"email" binding:"required"`
-	ID       int64  `json:"user_id" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	// ID задаёт только администратор или клиент в режиме импорта, иначе его выделяет сервер
+	ID int64 `json:"user_id"`
+}
+
+type loginRequest struct {
+	UserID   int64  `json:"user_id" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type loginResponse struct {
+	UserID int64  `json:"user_id"`
+	Token  string `json:"token"`
+}
+
+type universalUser struct {
+	Nickname         string       `json:"nickname" binding:"required"`
+	Email            string       `json:"email" binding:"required"`
+	ID               int64        `json:"user_id" binding:"required"`
+	DisplayName      string       `json:"display_name"`
+	AvatarURL        string       `json:"avatar_url"`
+	RegistrationDate time.Time    `json:"registration_date"`
+	Rating           ratingResult `json:"rating"`
+	DeletedAt        *time.Time   `json:"deleted_at,omitempty"`
+}
+
+// publicUser - профиль, который видит любой клиент: без email.
+type publicUser struct {
+	ID               int64        `json:"user_id"`
+	Nickname         string       `json:"nickname"`
+	DisplayName      string       `json:"display_name"`
+	AvatarURL        string       `json:"avatar_url"`
+	RegistrationDate time.Time    `json:"registration_date"`
+	Rating           ratingResult `json:"rating"`
+}
+
+type ratingResult struct {
+	Score float64 `json:"score"`
+	Votes int64   `json:"votes"`
 }
 
 type adResponse struct {
-	ID           int64     `json:"id"`
-	Title        string    `json:"title"`
-	Text         string    `json:"text"`
-	AuthorID     int64     `json:"author_id"`
-	Published    bool      `json:"published"`
-	CreationDate time.Time `json:"creation_date"`
-	UpdateDate   time.Time `json:"update_date"`
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	Text         string `json:"text"`
+	AuthorID     int64  `json:"author_id"`
+	Published    bool   `json:"published"`
+	Status       string `json:"status"`
+	RejectReason string `json:"reject_reason,omitempty"`
+	adAttributes
+	Images       []imageResponse `json:"images"`
+	Version      int64           `json:"version"`
+	CreationDate time.Time       `json:"creation_date"`
+	UpdateDate   time.Time       `json:"update_date"`
+	DeletedAt    *time.Time      `json:"deleted_at,omitempty"`
+}
+
+type imageResponse struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+func newAdResponse(ad ads.Ad) adResponse {
+	images := []imageResponse{}
+	for _, img := range ad.Images {
+		images = append(images, imageResponse{
+			ID:           img.ID,
+			URL:          app.ImageURL(ad.ID, img, false),
+			ThumbnailURL: app.ImageURL(ad.ID, img, true),
+			ContentType:  img.ContentType,
+			Size:         img.Size,
+			Width:        img.Width,
+			Height:       img.Height,
+		})
+	}
+	return adResponse{
+		ID:           ad.ID,
+		Title:        ad.Title,
+		Text:         ad.Text,
+		AuthorID:     ad.AuthorID,
+		Published:    ad.Published,
+		Status:       string(ad.Status),
+		RejectReason: ad.RejectReason,
+		adAttributes: newAdAttributes(ad.Attributes),
+		Images:       images,
+		Version:      ad.Version,
+		CreationDate: ad.CreationDate,
+		UpdateDate:   ad.UpdateDate,
+		DeletedAt:    ad.DeletedAt,
+	}
 }
 
 type changeAdStatusRequest struct {
-	Published bool  `json:"published"`
-	UserID    int64 `json:"user_id" binding:"required"`
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
 }
 
 type changeUserStatusRequest struct {
 	Nickname string `json:"nickname" binding:"required"`
 	Email    string `json:"email" binding:"required"`
+	// Профиль заменяется целиком: не переданные поля очищаются
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
+func (r changeUserStatusRequest) profile() *user.Profile {
+	return &user.Profile{DisplayName: r.DisplayName, AvatarURL: r.AvatarURL}
 }
 
 type updateAdRequest struct {
-	Title  string `json:"title" binding:"required"`
-	Text   string `json:"text" binding:"required"`
-	UserID int64  `json:"user_id" binding:"required"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	adAttributes
+}
+
+type categoryResponse struct {
+	ID       string             `json:"id"`
+	Name     string             `json:"name"`
+	Children []categoryResponse `json:"children,omitempty"`
+}
+
+func newCategoryResponses(categories []ads.Category) []categoryResponse {
+	res := []categoryResponse{}
+	for _, c := range categories {
+		res = append(res, categoryResponse{
+			ID:       c.ID,
+			Name:     c.Name,
+			Children: newCategoryResponses(c.Children),
+		})
+	}
+	return res
+}
+
+func CategoriesSuccessResponse(categories []ads.Category) *gin.H {
+	return &gin.H{
+		"data":  newCategoryResponses(categories),
+		"error": nil,
+	}
 }
 
 func AdSuccessResponse(ad *ads.Ad) *gin.H {
 	return &gin.H{
-		"data": adResponse{
-			ID:           ad.ID,
-			Title:        ad.Title,
-			Text:         ad.Text,
-			AuthorID:     ad.AuthorID,
-			Published:    ad.Published,
-			CreationDate: ad.CreationDate,
-			UpdateDate:   ad.UpdateDate,
-		},
+		"data":  newAdResponse(*ad),
 		"error": nil,
 	}
 }
@@ -65,26 +221,99 @@ func AdSuccessResponse(ad *ads.Ad) *gin.H {
 func UserSuccessResponse(u *user.User) *gin.H {
 	return &gin.H{
 		"data": universalUser{
-			ID:       u.ID,
-			Nickname: u.Nickname,
-			Email:    u.Email,
+			ID:               u.ID,
+			Nickname:         u.Nickname,
+			Email:            u.Email,
+			DisplayName:      u.DisplayName,
+			AvatarURL:        u.AvatarURL,
+			RegistrationDate: u.RegistrationDate,
+			Rating:           ratingResult{Score: u.Rating.Score, Votes: u.Rating.Votes},
+			DeletedAt:        u.DeletedAt,
+		},
+		"error": nil,
+	}
+}
+
+func PublicUserResponse(u *user.User) *gin.H {
+	return &gin.H{
+		"data": publicUser{
+			ID:               u.ID,
+			Nickname:         u.Nickname,
+			DisplayName:      u.DisplayName,
+			AvatarURL:        u.AvatarURL,
+			RegistrationDate: u.RegistrationDate,
+			Rating:           ratingResult{Score: u.Rating.Score, Votes: u.Rating.Votes},
 		},
 		"error": nil,
 	}
 }
 
+type searchAdsQuery struct {
+	Query string `form:"q" binding:"required"`
+	Limit int    `form:"limit"`
+}
+
+type searchResultResponse struct {
+	adResponse
+	Score float64 `json:"score"`
+}
+
+func SearchSuccessResponse(results []app.SearchResult) *gin.H {
+	res := []searchResultResponse{}
+	for _, r := range results {
+		res = append(res, searchResultResponse{
+			adResponse: newAdResponse(r.Ad),
+			Score:      r.Score,
+		})
+	}
+	return &gin.H{
+		"data":  res,
+		"error": nil,
+	}
+}
+
+type adsByTitleQuery struct {
+	pageQuery
+	Title string `form:"title"`
+}
+
+type revisionResponse struct {
+	AdID         int64     `json:"ad_id"`
+	Number       int64     `json:"number"`
+	Title        string    `json:"title"`
+	Text         string    `json:"text"`
+	EditorID     int64     `json:"editor_id"`
+	CreationDate time.Time `json:"creation_date"`
+}
+
+func RevisionsSuccessResponse(revs []ads.Revision) *gin.H {
+	res := []revisionResponse{}
+	for _, rev := range revs {
+		res = append(res, revisionResponse{
+			AdID:         rev.AdID,
+			Number:       rev.Number,
+			Title:        rev.Title,
+			Text:         rev.Text,
+			EditorID:     rev.EditorID,
+			CreationDate: rev.CreationDate,
+		})
+	}
+	return &gin.H{
+		"data":  res,
+		"error": nil,
+	}
+}
+
+func AdPageSuccessResponse(page *app.AdsPage) *gin.H {
+	res := AdSuccessResponseList(&page.Ads)
+	(*res)["next_cursor"] = page.NextCursor
+	return res
+}
+
 func AdSuccessResponseList(ads *[]ads.Ad) *gin.H {
 	adss := []adResponse{}
 	for _, ad := range *ads {
-		adss = append(adss, adResponse{
-			ID:           ad.ID,
-			Title:        ad.Title,
-			Text:         ad.Text,
-			AuthorID:     ad.AuthorID,
-			Published:    ad.Published,
-			CreationDate: ad.CreationDate,
-			UpdateDate:   ad.UpdateDate,
-		})
+		adss = append(adss, newAdResponse(ad))
 	}
 	return &gin.H{
 		"data":  adss,
@@ -92,13 +321,216 @@ func AdSuccessResponseList(ads *[]ads.Ad) *gin.H {
 	}
 }
 
-type deleteAdRequest struct {
-	UserID int64 `json:"user_id" binding:"required"`
+type pageQuery struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+	Sort   string `form:"sort"`
+	Order  string `form:"order"`
 }
 
-func AdErrorResponse(err error) *gin.H {
+func (q pageQuery) pagination() (app.Pagination, error) {
+	p := app.Pagination{Limit: q.Limit, Cursor: q.Cursor}
+
+	switch q.Sort {
+	case "", "id":
+		p.SortBy = app.SortByID
+	case "creation_date":
+		p.SortBy = app.SortByCreationDate
+	case "update_date":
+		p.SortBy = app.SortByUpdateDate
+	case "title":
+		p.SortBy = app.SortByTitle
+	default:
+		return app.Pagination{}, fmt.Errorf("invalid sort value %q", q.Sort)
+	}
+
+	switch q.Order {
+	case "", "asc":
+	case "desc":
+		p.Desc = true
+	default:
+		return app.Pagination{}, fmt.Errorf("invalid order value %q", q.Order)
+	}
+
+	return p, nil
+}
+
+type listAdsQuery struct {
+	pageQuery
+	Published   string    `form:"published"`
+	Status      string    `form:"status"`
+	AuthorID    *int64    `form:"author_id"`
+	CreatedFrom time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo   time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedFrom time.Time `form:"updated_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedTo   time.Time `form:"updated_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Title       string    `form:"title"`
+	Text        string    `form:"text"`
+	Category    string    `form:"category"`
+	Tags        []string  `form:"tag"`
+	Currency    string    `form:"currency"`
+	PriceMin    int64     `form:"price_min"`
+	PriceMax    int64     `form:"price_max"`
+	Location    string    `form:"location"`
+}
+
+func (q listAdsQuery) filter() (app.Filter, error) {
+	opts := []app.FilterOption{
+		app.CreatedBetween(q.CreatedFrom, q.CreatedTo),
+		app.UpdatedBetween(q.UpdatedFrom, q.UpdatedTo),
+		app.TitleContains(q.Title),
+		app.TextContains(q.Text),
+		app.InCategory(q.Category),
+		app.WithTags(q.Tags...),
+		app.PriceBetween(q.Currency, q.PriceMin, q.PriceMax),
+		app.AtLocation(q.Location),
+	}
+
+	switch q.Published {
+	case "", "true":
+		opts = append(opts, app.WithPublished(app.OnlyPublished))
+	case "false":
+		opts = append(opts, app.WithPublished(app.OnlyUnpublished))
+	case "all":
+		opts = append(opts, app.WithPublished(app.AnyPublished))
+	default:
+		return app.Filter{}, fmt.Errorf("invalid published value %q", q.Published)
+	}
+
+	if q.Status != "" {
+		status := ads.Status(q.Status)
+		if !status.Valid() {
+			return app.Filter{}, fmt.Errorf("invalid status value %q", q.Status)
+		}
+		opts = append(opts, app.WithStatus(status))
+	}
+
+	if q.AuthorID != nil {
+		opts = append(opts, app.WithAuthor(*q.AuthorID))
+	}
+
+	return app.NewFilter(opts...), nil
+}
+
+type messageRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+type markReadRequest struct {
+	// UpTo - ID последнего прочитанного сообщения, 0 - все сообщения
+	UpTo int64 `json:"up_to"`
+}
+
+type messageResponse struct {
+	ID           int64      `json:"id"`
+	ThreadID     int64      `json:"thread_id"`
+	SenderID     int64      `json:"sender_id"`
+	Text         string     `json:"text"`
+	ReadAt       *time.Time `json:"read_at"`
+	CreationDate time.Time  `json:"creation_date"`
+}
+
+func newMessageResponse(msg chat.Message) messageResponse {
+	return messageResponse{
+		ID:           msg.ID,
+		ThreadID:     msg.ThreadID,
+		SenderID:     msg.SenderID,
+		Text:         msg.Text,
+		ReadAt:       msg.ReadAt,
+		CreationDate: msg.CreationDate,
+	}
+}
+
+type threadResponse struct {
+	ID           int64           `json:"id"`
+	AdID         int64           `json:"ad_id"`
+	BuyerID      int64           `json:"buyer_id"`
+	SellerID     int64           `json:"seller_id"`
+	CreationDate time.Time       `json:"creation_date"`
+	UpdateDate   time.Time       `json:"update_date"`
+	LastMessage  messageResponse `json:"last_message"`
+	Unread       int64           `json:"unread"`
+}
+
+func MessageSuccessResponse(msg *chat.Message) *gin.H {
+	return &gin.H{
+		"data":  newMessageResponse(*msg),
+		"error": nil,
+	}
+}
+
+func MessagesSuccessResponse(msgs []chat.Message) *gin.H {
+	res := []messageResponse{}
+	for _, msg := range msgs {
+		res = append(res, newMessageResponse(msg))
+	}
+	return &gin.H{
+		"data":  res,
+		"error": nil,
+	}
+}
+
+func ThreadsSuccessResponse(summaries []chat.Summary) *gin.H {
+	res := []threadResponse{}
+	for _, s := range summaries {
+		res = append(res, threadResponse{
+			ID:           s.ID,
+			AdID:         s.AdID,
+			BuyerID:      s.BuyerID,
+			SellerID:     s.SellerID,
+			CreationDate: s.CreationDate,
+			UpdateDate:   s.UpdateDate,
+			LastMessage:  newMessageResponse(s.LastMessage),
+			Unread:       s.Unread,
+		})
+	}
 	return &gin.H{
+		"data":  res,
+		"error": nil,
+	}
+}
+
+func LoginSuccessResponse(userID int64, token string) *gin.H {
+	return &gin.H{
+		"data": loginResponse{
+			UserID: userID,
+			Token:  token,
+		},
+		"error": nil,
+	}
+}
+
+type fieldErrorResponse struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// respondError отвечает ошибкой app с кодом по errmap. Текст неизвестной ошибки может
+// раскрыть устройство сервера, поэтому клиент получает errmap.ErrInternal, а сама
+// ошибка попадает в журнал запроса через c.Error.
+func respondError(c *gin.Context, err error) {
+	code := errmap.HTTPStatus(err)
+	if code == http.StatusInternalServerError {
+		_ = c.Error(err)
+		err = errmap.ErrInternal
+	}
+	c.JSON(code, AdErrorResponse(err))
+}
+
+// AdErrorResponse для ошибок валидации дополнительно перечисляет поля в "fields"
+func AdErrorResponse(err error) *gin.H {
+	resp := gin.H{
 		"data":  nil,
 		"error": err.Error(),
 	}
+
+	var verr *app.ValidationError
+	if errors.As(err, &verr) {
+		fields := make([]fieldErrorResponse, 0, len(verr.Fields))
+		for _, f := range verr.Fields {
+			fields = append(fields, fieldErrorResponse{Field: f.Field, Reason: f.Reason})
+		}
+		resp["fields"] = fields
+	}
+	return &resp
 }
@@ -4,16 +4,43 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"homework10/internal/app"
+	"homework10/internal/auth"
 )
 
-func AppRouter(r gin.IRoutes, a app.App) {
-	r.POST("/ads", createAd(a))
-	r.PUT("/ads/:ad_id/status", changeAdStatus(a))
-	r.PUT("/ads/:ad_id", updateAd(a))
+func AppRouter(r gin.IRoutes, a app.App, tokens *auth.Tokens) {
+	authorized := authRequired(tokens)
+
+	r.POST("/login", login(a, tokens))
+	r.POST("/ads", authorized, createAd(a))
+	r.PUT("/ads/:ad_id/status", authorized, changeAdStatus(a))
+	r.PUT("/ads/:ad_id", authorized, updateAd(a))
+	r.GET("/ads/:ad_id", getAd(a))
+	r.GET("/ads/:ad_id/revisions", listAdRevisions(a))
+	r.POST("/ads/:ad_id/revisions/:number/restore", authorized, restoreAdRevision(a))
+	r.POST("/ads/:ad_id/images", authorized, uploadAdImage(a))
+	r.GET("/ads/:ad_id/images/:image_id", getAdImage(a, false))
+	r.GET("/ads/:ad_id/images/:image_id/thumbnail", getAdImage(a, true))
 	r.GET("/ads", listAds(a))
-	r.POST("/users", createUser(a))
-	r.PUT("/users/:user_id", changeUserInfo(a))
+	r.GET("/categories", listCategories())
+	r.POST("/users", authOptional(tokens), createUser(a))
+	r.GET("/users/:user_id", authOptional(tokens), getUser(a))
+	r.GET("/users/:user_id/ads", listUserAds(a))
+	r.PUT("/users/:user_id", authorized, changeUserInfo(a))
 	r.GET("/ads/by_title", getAdsByTitle(a))
-	r.DELETE("/ads/:ad_id", deleteAd(a))
-	r.DELETE("/users/:user_id", deleteUser(a))
+	r.GET("/ads/search", searchAds(a))
+	r.GET("/ads/live", liveAds(a))
+	r.DELETE("/ads/:ad_id", authorized, deleteAd(a))
+	r.DELETE("/users/:user_id", authorized, deleteUser(a))
+	r.GET("/users/:user_id/favorites", authorized, listFavorites(a))
+	r.POST("/users/:user_id/favorites/:ad_id", authorized, addFavorite(a))
+	r.DELETE("/users/:user_id/favorites/:ad_id", authorized, removeFavorite(a))
+	r.GET("/users/:user_id/threads", authorized, listThreads(a))
+	r.POST("/ads/:ad_id/messages", authorized, contactSeller(a))
+	r.GET("/threads/:thread_id/messages", authorized, listMessages(a))
+	r.POST("/threads/:thread_id/messages", authorized, sendMessage(a))
+	r.POST("/threads/:thread_id/read", authorized, markThreadRead(a))
+
+	r.GET("/admin/ads/deleted", authorized, listDeletedAds(a))
+	r.POST("/admin/ads/:ad_id/restore", authorized, restoreAd(a))
+	r.POST("/admin/users/:user_id/restore", authorized, restoreUser(a))
 }
@@ -4,14 +4,20 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"homework10/internal/app"
+	"homework10/internal/auth"
+	"homework10/internal/metrics"
 )
 
-func NewHTTPServer(port string, a app.App) *http.Server {
+func NewHTTPServer(port string, a app.App, tokens *auth.Tokens, logger *zap.Logger, m *metrics.Metrics) *http.Server {
 	gin.SetMode(gin.ReleaseMode)
 	handler := gin.New()
+	// Значения контекста запроса (аутентифицированный пользователь) доступны через gin.Context
+	handler.ContextWithFallback = true
+	handler.Use(requestID(), traceRequest(), accessLog(logger), observe(m))
 	api := handler.Group("/api/v1")
-	AppRouter(api, a)
+	AppRouter(api, a, tokens)
 	s := &http.Server{Addr: port, Handler: handler}
 
 	return s
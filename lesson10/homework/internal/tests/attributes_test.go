@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestAdAttributes(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+
+	ad, err := client.createAdWith(123, map[string]any{
+		"title":    "car",
+		"text":     "good one",
+		"category": "transport/cars",
+		"tags":     []string{"Diesel", "diesel", " Red "},
+		"price":    map[string]any{"amount": 150000000, "currency": "rub"},
+		"location": "Moscow",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "transport/cars", ad.Data.Category)
+	assert.Equal(t, []string{"diesel", "red"}, ad.Data.Tags)
+	assert.Equal(t, &priceData{Amount: 150000000, Currency: "RUB"}, ad.Data.Price)
+	assert.Equal(t, "Moscow", ad.Data.Location)
+
+	// PUT заменяет атрибуты целиком
+	updated, err := client.updateAdWith(123, ad.Data.ID, map[string]any{
+		"title": "car",
+		"text":  "sold",
+		"tags":  []string{"sold"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Data.Category)
+	assert.Equal(t, []string{"sold"}, updated.Data.Tags)
+	assert.Nil(t, updated.Data.Price)
+	assert.Empty(t, updated.Data.Location)
+}
+
+func TestAdAttributesValidation(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+
+	tooMany := make([]string, app.MaxTags+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("t", i+1)
+	}
+	for name, attrs := range map[string]map[string]any{
+		"unknown category": {"category": "transport/boats"},
+		"too many tags":    {"tags": tooMany},
+		"empty tag":        {"tags": []string{"ok", " "}},
+		"long tag":         {"tags": []string{strings.Repeat("t", 31)}},
+		"negative price":   {"price": map[string]any{"amount": -1, "currency": "RUB"}},
+		"no currency":      {"price": map[string]any{"amount": 100}},
+		"unknown currency": {"price": map[string]any{"amount": 100, "currency": "XXX"}},
+		"long location":    {"location": strings.Repeat("l", 100)},
+	} {
+		attrs["title"], attrs["text"] = "hello", "world"
+		_, err := client.createAdWith(123, attrs)
+		assert.ErrorIs(t, err, ErrUnprocessable, name)
+	}
+
+	// Ошибки всех полей возвращаются вместе
+	a := newTestApp()
+	ctx := context.Background()
+	_, err := a.CreateUser(ctx, "nickname", "somemail@mail.ru", testPassword, 123)
+	assert.NoError(t, err)
+	_, err = a.CreateAd(app.WithUser(ctx, 123), "", "text", ads.Attributes{
+		Category: "unknown",
+		Tags:     []string{""},
+		Price:    ads.Price{Amount: 1},
+	})
+	var validationErr *app.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []app.FieldError{
+		{Field: "title", Reason: "length must be between 1 and 99"},
+		{Field: "tags", Reason: "length must be between 1 and 30"},
+		{Field: "category", Reason: "unknown category"},
+		{Field: "price", Reason: "unknown currency"},
+	}, validationErr.Fields)
+}
+
+func TestFilterByAttributes(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+
+	create := func(category string, tags []string, amount int64, currency, location string) int64 {
+		ad, err := client.createAdWith(123, map[string]any{
+			"title":    "ad",
+			"text":     "text",
+			"category": category,
+			"tags":     tags,
+			"price":    map[string]any{"amount": amount, "currency": currency},
+			"location": location,
+		})
+		require.NoError(t, err)
+		return ad.Data.ID
+	}
+	car := create("transport/cars", []string{"diesel", "red"}, 1000, "RUB", "Moscow")
+	bike := create("transport/bicycles", []string{"red"}, 500, "USD", "Kazan")
+	phone := create("electronics/phones", nil, 0, "", "moscow")
+
+	ids := func(query url.Values) []int64 {
+		query.Set("published", "all")
+		list, err := client.listAdsQuery(query)
+		require.NoError(t, err)
+		var res []int64
+		for _, ad := range list.Data {
+			res = append(res, ad.ID)
+		}
+		return res
+	}
+
+	assert.Equal(t, []int64{car, bike}, ids(url.Values{"category": {"transport"}}))
+	assert.Equal(t, []int64{car}, ids(url.Values{"category": {"transport/cars"}}))
+	assert.Equal(t, []int64{car, bike}, ids(url.Values{"tag": {"Red"}}))
+	assert.Equal(t, []int64{car}, ids(url.Values{"tag": {"red", "diesel"}}))
+	assert.Equal(t, []int64{bike}, ids(url.Values{"currency": {"usd"}}))
+	assert.Equal(t, []int64{car}, ids(url.Values{"currency": {"RUB"}, "price_min": {"1000"}, "price_max": {"1000"}}))
+	assert.Empty(t, ids(url.Values{"currency": {"RUB"}, "price_max": {"999"}}))
+	assert.Equal(t, []int64{car, phone}, ids(url.Values{"location": {"MOSCOW"}}))
+
+	_, err := client.listAdsQuery(url.Values{"price_min": {"100"}})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestListCategories(t *testing.T) {
+	client := getTestClient()
+
+	res, err := client.listCategories()
+	require.NoError(t, err)
+	require.Len(t, res.Data, len(ads.Categories()))
+	assert.Equal(t, "transport", res.Data[0].ID)
+	assert.Equal(t, "transport/cars", res.Data[0].Children[0].ID)
+	for _, c := range res.Data {
+		assert.True(t, ads.KnownCategory(c.ID))
+		for _, child := range c.Children {
+			assert.True(t, ads.InCategory(child.ID, c.ID))
+		}
+	}
+}
+
+func TestGRPCAdAttributes(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	userCtx := asUser(t, client, ctx, 123)
+
+	ad, err := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", Attributes: &grpcPort.AdAttributes{
+		Category: "realty/rooms",
+		Tags:     []string{"Center"},
+		Price:    &grpcPort.Price{Amount: 2500000, Currency: "EUR"},
+		Location: "Berlin",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "realty/rooms", ad.Attributes.Category)
+	assert.Equal(t, []string{"center"}, ad.Attributes.Tags)
+	assert.Equal(t, int64(2500000), ad.Attributes.Price.Amount)
+
+	// Без attributes прежние атрибуты сохраняются
+	updated, err := client.UpdateAd(userCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "hello", Text: "again"})
+	require.NoError(t, err)
+	assert.Equal(t, "realty/rooms", updated.Attributes.Category)
+	assert.Equal(t, "Berlin", updated.Attributes.Location)
+
+	updated, err = client.UpdateAd(userCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "hello", Text: "again", Attributes: &grpcPort.AdAttributes{Category: "realty"}})
+	require.NoError(t, err)
+	assert.Equal(t, "realty", updated.Attributes.Category)
+	assert.Nil(t, updated.Attributes.Price)
+	assert.Empty(t, updated.Attributes.Tags)
+
+	list, err := client.ListAds(ctx, &grpcPort.FilterRequest{Published: grpcPort.PublishedFilter_PUBLISHED_FILTER_ALL, Category: "realty"})
+	require.NoError(t, err)
+	assert.Len(t, list.List, 1)
+	list, err = client.ListAds(ctx, &grpcPort.FilterRequest{Published: grpcPort.PublishedFilter_PUBLISHED_FILTER_ALL, Category: "realty/rooms"})
+	require.NoError(t, err)
+	assert.Empty(t, list.List)
+
+	categories, err := client.ListCategories(ctx, &grpcPort.ListCategoriesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, categories.Categories, len(ads.Categories()))
+}
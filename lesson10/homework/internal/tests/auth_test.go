@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogin(t *testing.T) {
+	client := getTestClient()
+	_, err := client.createUser(123, "user", "somemail@mail.com")
+	assert.NoError(t, err)
+
+	response, err := client.login(123, testPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(123), response.Data.UserID)
+	assert.NotEmpty(t, response.Data.Token)
+
+	_, err = client.login(123, "wrong password")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = client.login(124, testPassword)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestInvalidToken(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+
+	client.tokens[123] += "x"
+	_, err := client.createAd(123, "hello", "world")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	delete(client.tokens, 123)
+	_, err = client.createAd(123, "hello", "world")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestActAsAnotherUser(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	_, _ = client.createUser(124, "user1", "somemail1@mail.com")
+
+	ad, err := client.createAd(123, "hello", "world")
+	assert.NoError(t, err)
+
+	// Токен пользователя 124 не даёт прав на объявление и профиль пользователя 123
+	client.tokens[123] = client.tokens[124]
+
+	_, err = client.deleteAd(123, ad.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.changeUserInfo(123, "hacker", "hacker@mail.com")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.deleteUserByID(123)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
@@ -2,7 +2,9 @@ package tests
 
 import (
 	"github.com/stretchr/testify/assert"
+	"net/url"
 	"testing"
+	"time"
 )
 
 func TestCreateAd(t *testing.T) {
@@ -27,7 +29,7 @@ func TestCreateUser(t *testing.T) {
 	assert.NoError(t, err)
 
 	_, err = client.createUser(123, "user", "somemail@mail.com")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrConflict)
 }
 
 func TestChangeAdStatus(t *testing.T) {
@@ -36,13 +38,17 @@ func TestChangeAdStatus(t *testing.T) {
 	response, err := client.createAd(123, "hello", "world")
 	assert.NoError(t, err)
 
-	response, err = client.changeAdStatus(123, response.Data.ID, true)
+	assert.Equal(t, "draft", response.Data.Status)
+
+	response, err = client.publishAd(123, response.Data.ID)
 	assert.NoError(t, err)
 	assert.True(t, response.Data.Published)
+	assert.Equal(t, "published", response.Data.Status)
 
-	response, err = client.changeAdStatus(123, response.Data.ID, false)
+	response, err = client.changeAdStatus(123, response.Data.ID, "archived", "")
 	assert.NoError(t, err)
 	assert.False(t, response.Data.Published)
+	assert.Equal(t, "archived", response.Data.Status)
 
 }
 
@@ -58,10 +64,10 @@ func TestUpdateAd(t *testing.T) {
 	assert.Equal(t, response.Data.Text, "мир")
 
 	response, err = client.updateAd(124, response.Data.ID, "привет", "мир")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnauthorized)
 
 	response, err = client.updateAd(123, response.Data.ID+1, "привет", "мир")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrNotFound)
 }
 
 func TestListAds(t *testing.T) {
@@ -70,7 +76,7 @@ func TestListAds(t *testing.T) {
 	response, err := client.createAd(123, "hello", "world")
 	assert.NoError(t, err)
 
-	publishedAd, err := client.changeAdStatus(123, response.Data.ID, true)
+	publishedAd, err := client.publishAd(123, response.Data.ID)
 	assert.NoError(t, err)
 
 	_, err = client.createAd(123, "title", "text")
@@ -93,10 +99,10 @@ func TestDeleteAd(t *testing.T) {
 	a, _ := client.createAd(123, "title", "text")
 
 	response, _ := client.deleteAd(a.Data.AuthorID, a.Data.ID)
-	_, err := client.changeAdStatus(123, response.Data.ID, true)
-	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err := client.changeAdStatus(123, response.Data.ID, "pending", "")
+	assert.ErrorIs(t, err, ErrNotFound)
 	_, err = client.deleteAd(a.Data.AuthorID, a.Data.ID)
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrNotFound)
 }
 
 func TestChangeUserInfo(t *testing.T) {
@@ -109,7 +115,7 @@ func TestChangeUserInfo(t *testing.T) {
 	assert.Equal(t, response.Data.Email, "somemailnew@mail.com")
 
 	_, err = client.changeUserInfo(124, "124", "qwerty@mail.ru")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnauthorized)
 }
 
 func TestDeleteUserByID(t *testing.T) {
@@ -124,7 +130,7 @@ func TestDeleteUserByID(t *testing.T) {
 	assert.Equal(t, response.Data.Email, a.Data.Email)
 
 	_, err = client.deleteUserByID(3)
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnauthorized)
 }
 
 func TestGetAdsByTitle(t *testing.T) {
@@ -157,9 +163,9 @@ func TestFilterByAuthor(t *testing.T) {
 	a, _ := client.createAd(123, "title", "text")
 	b, _ := client.createAd(123, "title", "text")
 	c, _ := client.createAd(124, "text", "title")
-	a, _ = client.changeAdStatus(123, a.Data.ID, true)
-	b, _ = client.changeAdStatus(123, b.Data.ID, true)
-	c, _ = client.changeAdStatus(124, c.Data.ID, true)
+	a, _ = client.publishAd(123, a.Data.ID)
+	b, _ = client.publishAd(123, b.Data.ID)
+	c, _ = client.publishAd(124, c.Data.ID)
 
 	ads, err := client.listAdsAuthor(123)
 	assert.NoError(t, err)
@@ -173,3 +179,106 @@ func TestFilterByAuthor(t *testing.T) {
 	assert.Equal(t, ads.Data[1].AuthorID, b.Data.AuthorID)
 	assert.Equal(t, ads.Data[1].Published, b.Data.Published)
 }
+
+func TestListAdsByStatus(t *testing.T) {
+	client := getTestClient()
+
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+
+	a, _ := client.createAd(123, "title", "text")
+	b, _ := client.createAd(123, "title", "text")
+	_, _ = client.publishAd(123, a.Data.ID)
+
+	ads, err := client.listAdsQuery(url.Values{"published": {"false"}})
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 1)
+	assert.Equal(t, b.Data.ID, ads.Data[0].ID)
+
+	ads, err = client.listAdsQuery(url.Values{"published": {"all"}})
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 2)
+
+	_, err = client.listAdsQuery(url.Values{"published": {"maybe"}})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestListAdsBySubstring(t *testing.T) {
+	client := getTestClient()
+
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+
+	a, _ := client.createAd(123, "Red bike for sale", "almost new")
+	b, _ := client.createAd(123, "Blue car", "Продаю машину")
+	_, _ = client.publishAd(123, a.Data.ID)
+	_, _ = client.publishAd(123, b.Data.ID)
+
+	ads, err := client.listAdsQuery(url.Values{"title": {"BIKE"}})
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 1)
+	assert.Equal(t, a.Data.ID, ads.Data[0].ID)
+
+	ads, err = client.listAdsQuery(url.Values{"text": {"машин"}})
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 1)
+	assert.Equal(t, b.Data.ID, ads.Data[0].ID)
+}
+
+func TestListAdsByAuthorAndDate(t *testing.T) {
+	client := getTestClient()
+
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	_, _ = client.createUser(124, "user1", "somemail1@mail.com")
+
+	a, _ := client.createAd(123, "title", "text")
+	b, _ := client.createAd(124, "title", "text")
+	_, _ = client.publishAd(123, a.Data.ID)
+	_, _ = client.publishAd(124, b.Data.ID)
+
+	ads, err := client.listAdsAuthor(124)
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 1)
+	assert.Equal(t, b.Data.ID, ads.Data[0].ID)
+
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	ads, err = client.listAdsQuery(url.Values{"created_from": {future}})
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 0)
+
+	ads, err = client.listAdsQuery(url.Values{"created_to": {future}})
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 2)
+
+	_, err = client.listAdsQuery(url.Values{"author_id": {"abc"}})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestListAdsPagination(t *testing.T) {
+	client := getTestClient()
+
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	for _, title := range []string{"b", "e", "a", "d", "c"} {
+		ad, _ := client.createAd(123, title, "text")
+		_, _ = client.publishAd(123, ad.Data.ID)
+	}
+
+	titles := []string{}
+	query := url.Values{"limit": {"2"}, "sort": {"title"}, "order": {"desc"}}
+	for i := 0; i < 3; i++ {
+		page, err := client.listAdsQuery(query)
+		assert.NoError(t, err)
+		for _, ad := range page.Data {
+			titles = append(titles, ad.Title)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+	assert.Equal(t, []string{"e", "d", "c", "b", "a"}, titles)
+
+	_, err := client.listAdsQuery(url.Values{"cursor": {"broken"}})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, err = client.listAdsQuery(url.Values{"sort": {"price"}})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
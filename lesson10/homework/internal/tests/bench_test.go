@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"homework10/internal/adapters/adrepo"
 	"homework10/internal/adapters/userrepo"
+	"homework10/internal/ads"
 	"strconv"
 	"testing"
 )
@@ -13,7 +14,7 @@ func BenchmarkRepo(b *testing.B) {
 	ctx := context.Background()
 	repo := adrepo.New()
 	for i := 0; i < b.N; i++ {
-		_ = repo.Add(ctx, fmt.Sprint("ad", i), "test ad", 1)
+		_, _ = repo.Add(ctx, fmt.Sprint("ad", i), "test ad", ads.Attributes{}, 1)
 	}
 }
 
@@ -21,6 +22,6 @@ func BenchmarkUser(b *testing.B) {
 	ctx := context.Background()
 	usRepo := userrepo.New()
 	for i := 0; i < b.N; i++ {
-		_ = usRepo.Create(ctx, fmt.Sprint("user", i), "somemail"+strconv.Itoa(i)+"@mail.ru", int64(i))
+		_, _ = usRepo.Create(ctx, fmt.Sprint("user", i), "somemail"+strconv.Itoa(i)+"@mail.ru", "", int64(i))
 	}
 }
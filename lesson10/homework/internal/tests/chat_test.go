@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestChatThread(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "seller", "seller@mail.com")
+	_, _ = client.createUser(124, "buyer", "buyer@mail.com")
+	car, err := client.createAd(123, "car", "good one")
+	require.NoError(t, err)
+	bike, err := client.createAd(123, "bike", "fast one")
+	require.NoError(t, err)
+	for _, id := range []int64{car.Data.ID, bike.Data.ID} {
+		_, err = client.publishAd(123, id)
+		require.NoError(t, err)
+	}
+
+	first, err := client.contactSeller(124, car.Data.ID, "still available?")
+	require.NoError(t, err)
+	assert.Equal(t, int64(124), first.Data.SenderID)
+	// Повторное обращение по объявлению попадает в ту же переписку
+	second, err := client.contactSeller(124, car.Data.ID, "hello?")
+	require.NoError(t, err)
+	assert.Equal(t, first.Data.ThreadID, second.Data.ThreadID)
+	threadID := first.Data.ThreadID
+
+	reply, err := client.sendMessage(123, threadID, "yes")
+	require.NoError(t, err)
+	other, err := client.contactSeller(124, bike.Data.ID, "and the bike?")
+	require.NoError(t, err)
+	assert.NotEqual(t, threadID, other.Data.ThreadID)
+
+	msgs, err := client.listMessages(123, threadID)
+	require.NoError(t, err)
+	require.Len(t, msgs.Data, 3)
+	assert.Equal(t, []string{"still available?", "hello?", "yes"}, []string{msgs.Data[0].Text, msgs.Data[1].Text, msgs.Data[2].Text})
+
+	// Переписка с самым новым сообщением первая
+	inbox, err := client.listThreads(123, 123)
+	require.NoError(t, err)
+	require.Len(t, inbox.Data, 2)
+	assert.Equal(t, other.Data.ThreadID, inbox.Data[0].ID)
+	assert.Equal(t, int64(1), inbox.Data[0].Unread)
+	assert.Equal(t, threadID, inbox.Data[1].ID)
+	assert.Equal(t, int64(2), inbox.Data[1].Unread)
+	assert.Equal(t, reply.Data.ID, inbox.Data[1].LastMessage.ID)
+
+	// Свои сообщения не отмечаются, а up_to ограничивает отмеченные
+	marked, err := client.markRead(123, threadID, first.Data.ID)
+	require.NoError(t, err)
+	require.Len(t, marked.Data, 1)
+	assert.Equal(t, first.Data.ID, marked.Data[0].ID)
+	assert.NotNil(t, marked.Data[0].ReadAt)
+	marked, err = client.markRead(123, threadID, 0)
+	require.NoError(t, err)
+	require.Len(t, marked.Data, 1)
+	assert.Equal(t, second.Data.ID, marked.Data[0].ID)
+	marked, err = client.markRead(123, threadID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, marked.Data)
+
+	msgs, err = client.listMessages(124, threadID)
+	require.NoError(t, err)
+	assert.NotNil(t, msgs.Data[0].ReadAt)
+	assert.NotNil(t, msgs.Data[1].ReadAt)
+	assert.Nil(t, msgs.Data[2].ReadAt)
+
+	inbox, err = client.listThreads(124, 124)
+	require.NoError(t, err)
+	require.Len(t, inbox.Data, 2)
+	assert.Equal(t, int64(1), inbox.Data[1].Unread)
+}
+
+func TestChatAccess(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "seller", "seller@mail.com")
+	_, _ = client.createUser(124, "buyer", "buyer@mail.com")
+	_, _ = client.createUser(125, "stranger", "stranger@mail.com")
+	ad, err := client.createAd(123, "car", "good one")
+	require.NoError(t, err)
+	// По черновику написать нельзя: покупатели его не видят
+	_, err = client.contactSeller(124, ad.Data.ID, "hello")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = client.publishAd(123, ad.Data.ID)
+	require.NoError(t, err)
+	msg, err := client.contactSeller(124, ad.Data.ID, "hello")
+	require.NoError(t, err)
+	threadID := msg.Data.ThreadID
+
+	_, err = client.contactSeller(123, ad.Data.ID, "my own ad")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.listMessages(125, threadID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.sendMessage(125, threadID, "hi")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.markRead(125, threadID, 0)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.listThreads(125, 124)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.sendMessage(124, threadID+100, "hi")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = client.contactSeller(124, ad.Data.ID+100, "hi")
+	assert.ErrorIs(t, err, ErrNotFound)
+	// Токен неизвестного клиенту пользователя не передаётся
+	_, err = client.listMessages(126, threadID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = client.sendMessage(124, threadID, "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.sendMessage(124, threadID, strings.Repeat("a", 1000))
+	assert.ErrorIs(t, err, ErrUnprocessable)
+
+	// После удаления объявления переписку можно читать, но не продолжать
+	_, err = client.deleteAd(123, ad.Data.ID)
+	require.NoError(t, err)
+	_, err = client.sendMessage(124, threadID, "still there?")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = client.contactSeller(124, ad.Data.ID, "hello")
+	assert.ErrorIs(t, err, ErrNotFound)
+	msgs, err := client.listMessages(124, threadID)
+	require.NoError(t, err)
+	assert.Len(t, msgs.Data, 1)
+}
+
+func TestGRPCChat(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "seller", Email: "seller@mail.com", UserId: 123, Password: testPassword})
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "buyer", Email: "buyer@mail.com", UserId: 124, Password: testPassword})
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "stranger", Email: "stranger@mail.com", UserId: 125, Password: testPassword})
+	sellerCtx, buyerCtx := asUser(t, client, ctx, 123), asUser(t, client, ctx, 124)
+	strangerCtx := asUser(t, client, ctx, 125)
+
+	ad, err := client.CreateAd(sellerCtx, &grpcPort.CreateAdRequest{Title: "car", Text: "good one"})
+	require.NoError(t, err)
+	grpcPublishAd(t, client, ctx, sellerCtx, ad.Id)
+
+	open := func(ctx context.Context) grpcPort.AdService_ChatClient {
+		stream, err := client.Chat(ctx)
+		require.NoError(t, err)
+		// Заголовки приходят после оформления подписки
+		_, err = stream.Header()
+		require.NoError(t, err)
+		return stream
+	}
+	seller, buyer, stranger := open(sellerCtx), open(buyerCtx), open(strangerCtx)
+
+	require.NoError(t, buyer.Send(&grpcPort.ChatRequest{RequestId: "1", Action: &grpcPort.ChatRequest_ContactSeller{
+		ContactSeller: &grpcPort.ContactSeller{AdId: ad.Id, Text: "still available?"},
+	}}))
+	var threadID int64
+	for _, stream := range []grpcPort.AdService_ChatClient{buyer, seller} {
+		ev, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, grpcPort.ChatEventType_CHAT_EVENT_TYPE_MESSAGE, ev.Type)
+		assert.Equal(t, int64(124), ev.Thread.BuyerId)
+		assert.Equal(t, int64(123), ev.Thread.SellerId)
+		require.Len(t, ev.Messages, 1)
+		assert.Equal(t, "still available?", ev.Messages[0].Text)
+		threadID = ev.Thread.Id
+	}
+
+	require.NoError(t, seller.Send(&grpcPort.ChatRequest{RequestId: "2", Action: &grpcPort.ChatRequest_MarkRead{
+		MarkRead: &grpcPort.MarkRead{ThreadId: threadID},
+	}}))
+	for _, stream := range []grpcPort.AdService_ChatClient{seller, buyer} {
+		ev, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, grpcPort.ChatEventType_CHAT_EVENT_TYPE_READ, ev.Type)
+		require.Len(t, ev.Messages, 1)
+		assert.NotNil(t, ev.Messages[0].ReadAt)
+	}
+
+	// Ошибку получает только отправивший запрос поток
+	require.NoError(t, stranger.Send(&grpcPort.ChatRequest{RequestId: "3", Action: &grpcPort.ChatRequest_SendMessage{
+		SendMessage: &grpcPort.SendMessage{ThreadId: threadID, Text: "hi"},
+	}}))
+	ev, err := stranger.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, grpcPort.ChatEventType_CHAT_EVENT_TYPE_ERROR, ev.Type)
+	assert.Equal(t, "3", ev.RequestId)
+	assert.Equal(t, int32(codes.PermissionDenied), ev.Code)
+
+	require.NoError(t, seller.Send(&grpcPort.ChatRequest{RequestId: "4", Action: &grpcPort.ChatRequest_SendMessage{
+		SendMessage: &grpcPort.SendMessage{ThreadId: threadID, Text: "yes"},
+	}}))
+	ev, err = buyer.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, grpcPort.ChatEventType_CHAT_EVENT_TYPE_MESSAGE, ev.Type)
+	assert.Equal(t, "yes", ev.Messages[0].Text)
+
+	for _, stream := range []grpcPort.AdService_ChatClient{seller, buyer, stranger} {
+		assert.NoError(t, stream.CloseSend())
+	}
+
+	threads, err := client.ListThreads(buyerCtx, &grpcPort.ListThreadsRequest{UserId: 124})
+	require.NoError(t, err)
+	require.Len(t, threads.Threads, 1)
+	assert.Equal(t, "yes", threads.Threads[0].LastMessage.Text)
+	assert.Equal(t, int64(1), threads.Threads[0].Unread)
+	msgs, err := client.ListMessages(sellerCtx, &grpcPort.ListMessagesRequest{ThreadId: threadID})
+	require.NoError(t, err)
+	assert.Len(t, msgs.Messages, 2)
+
+	_, err = client.ListMessages(strangerCtx, &grpcPort.ListMessagesRequest{ThreadId: threadID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.ListThreads(strangerCtx, &grpcPort.ListThreadsRequest{UserId: 124})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	stream, err := client.Chat(ctx)
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
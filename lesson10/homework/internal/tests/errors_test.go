@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/app"
+	"homework10/internal/ports/errmap"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestErrMap(t *testing.T) {
+	validation := &app.ValidationError{Fields: []app.FieldError{{Field: "title", Reason: "too long"}}}
+
+	tests := []struct {
+		name string
+		err  error
+		http int
+		grpc codes.Code
+	}{
+		{"validation", validation, http.StatusUnprocessableEntity, codes.InvalidArgument},
+		{"wrong format", app.ErrWrongFormat, http.StatusBadRequest, codes.InvalidArgument},
+		{"not found", app.ErrNotFound, http.StatusNotFound, codes.NotFound},
+		{"wrapped not found", fmt.Errorf("ad 1: %w", app.ErrNotFound), http.StatusNotFound, codes.NotFound},
+		{"already exists", app.ErrAlreadyExists, http.StatusConflict, codes.AlreadyExists},
+		{"access denied", app.ErrAccessDenied, http.StatusForbidden, codes.PermissionDenied},
+		{"unauthenticated", app.ErrUnauthenticated, http.StatusUnauthorized, codes.Unauthenticated},
+		{"invalid transition", app.ErrInvalidTransition, http.StatusConflict, codes.FailedPrecondition},
+		{"version conflict", app.ErrVersionConflict, http.StatusPreconditionFailed, codes.Aborted},
+		{"author deleted", app.ErrAuthorDeleted, http.StatusConflict, codes.FailedPrecondition},
+		{"unknown", errors.New("connection refused"), http.StatusInternalServerError, codes.Internal},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.http, errmap.HTTPStatus(test.err))
+			assert.Equal(t, test.grpc, status.Code(errmap.GRPCError(test.err)))
+		})
+	}
+}
+
+func TestValidationErrorFields(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+
+	data, err := json.Marshal(map[string]any{"title": strings.Repeat("a", 100), "text": strings.Repeat("a", 500)})
+	assert.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, client.baseURL+"/api/v1/ads", bytes.NewReader(data))
+	assert.NoError(t, err)
+	req.Header.Add("Content-Type", "application/json")
+	client.authorize(req, 123)
+
+	resp, err := client.client.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	var body struct {
+		Fields []struct {
+			Field  string `json:"field"`
+			Reason string `json:"reason"`
+		} `json:"fields"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Len(t, body.Fields, 2)
+	assert.Equal(t, "title", body.Fields[0].Field)
+	assert.Equal(t, "text", body.Fields[1].Field)
+}
+
+func TestGRPCErrorCodes(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	userCtx := asUser(t, client, ctx, 123)
+
+	_, err := client.UpdateAd(userCtx, &grpcPort.UpdateAdRequest{AdId: 42, Title: "title", Text: "text"})
+	assert.ErrorIs(t, err, ErrorNotFound)
+
+	_, err = client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: strings.Repeat("a", 100), Text: "text"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Len(t, st.Details(), 1)
+	br, ok := st.Details()[0].(*errdetails.BadRequest)
+	assert.True(t, ok)
+	assert.Equal(t, "title", br.GetFieldViolations()[0].GetField())
+}
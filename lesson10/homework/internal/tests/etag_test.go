@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+// conditional выполняет запрос к объявлению adID от имени userID с заголовком If-Match
+// (если он не пуст) и возвращает ответ вместе с его ETag.
+func (tc *testClient) conditional(method, path string, userID, adID int64, ifMatch string, body any) (adResponse, string, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return adResponse{}, "", fmt.Errorf("unable to marshal: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d"+path, adID), reader)
+	if err != nil {
+		return adResponse{}, "", fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	tc.authorize(req, userID)
+
+	var response adResponse
+	header, err := tc.getResponseHeader(req, &response)
+	if err != nil {
+		return adResponse{}, "", err
+	}
+	return response, header.Get("ETag"), nil
+}
+
+func TestIfMatchUpdate(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	ad, err := client.createAd(123, "hello", "world")
+	assert.NoError(t, err)
+
+	update := map[string]any{"title": "first", "text": "tool"}
+	res, etag, err := client.conditional(http.MethodPut, "", 123, ad.Data.ID, `"1"`, update)
+	assert.NoError(t, err)
+	assert.Equal(t, `"2"`, etag)
+	assert.Equal(t, int64(2), res.Data.Version)
+
+	// Второй инструмент редактирует по устаревшей версии
+	update = map[string]any{"title": "second", "text": "tool"}
+	_, _, err = client.conditional(http.MethodPut, "", 123, ad.Data.ID, `"1"`, update)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	_, _, err = client.conditional(http.MethodPut, "", 123, ad.Data.ID, `W/"2"`, update)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	_, _, err = client.conditional(http.MethodPut, "", 123, ad.Data.ID, `"1", W/"2", "3"`, update)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+	// Из списка достаточно одной совпавшей сильной метки
+	res, etag, err = client.conditional(http.MethodPut, "", 123, ad.Data.ID, `"1", "2"`, update)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", res.Data.Title)
+	assert.Equal(t, `"3"`, etag)
+
+	update = map[string]any{"title": "third", "text": "tool"}
+	res, etag, err = client.conditional(http.MethodPut, "", 123, ad.Data.ID, "*", update)
+	assert.NoError(t, err)
+	assert.Equal(t, "third", res.Data.Title)
+	assert.Equal(t, `"4"`, etag)
+}
+
+func TestIfMatchStatusAndDelete(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	ad, err := client.createAd(123, "hello", "world")
+	assert.NoError(t, err)
+
+	submit := map[string]any{"status": "pending"}
+	_, _, err = client.conditional(http.MethodPut, "/status", 123, ad.Data.ID, `"2"`, submit)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	_, etag, err := client.conditional(http.MethodPut, "/status", 123, ad.Data.ID, `"1"`, submit)
+	assert.NoError(t, err)
+
+	_, _, err = client.conditional(http.MethodDelete, "", 123, ad.Data.ID, `"1"`, nil)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	deleted, etag, err := client.conditional(http.MethodDelete, "", 123, ad.Data.ID, etag, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf(`"%d"`, deleted.Data.Version), etag)
+}
+
+func TestGetAdETag(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	ad, err := client.createAd(123, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.updateAd(123, ad.Data.ID, "first", "edit")
+	assert.NoError(t, err)
+
+	// Инструмент без собственных правок узнаёт текущую версию чтением
+	res, etag, err := client.conditional(http.MethodGet, "", 0, ad.Data.ID, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", res.Data.Title)
+	assert.Equal(t, `"2"`, etag)
+
+	_, _, err = client.conditional(http.MethodPut, "", 123, ad.Data.ID, etag, map[string]any{"title": "second", "text": "edit"})
+	assert.NoError(t, err)
+	_, _, err = client.conditional(http.MethodPut, "", 123, ad.Data.ID, etag, map[string]any{"title": "third", "text": "edit"})
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+	_, _, err = client.conditional(http.MethodGet, "", 0, ad.Data.ID+1, "", nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGRPCExpectedVersion(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	userCtx := asUser(t, client, ctx, 123)
+	ad, err := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"})
+	assert.NoError(t, err)
+
+	updated, err := client.UpdateAd(userCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "first", Text: "tool", ExpectedVersion: ad.Version})
+	assert.NoError(t, err)
+	assert.Equal(t, ad.Version+1, updated.Version)
+
+	_, err = client.UpdateAd(userCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "second", Text: "tool", ExpectedVersion: ad.Version})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestBusFilter(t *testing.T) {
+	bus := app.NewBus(app.DefaultEventBuffer)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub := bus.Subscribe(ctx, app.NewFilter(app.WithAuthor(123)))
+	bus.Publish(app.AdEvent{Type: app.EventPublished, Ad: ads.Ad{ID: 1, AuthorID: 124, Published: true}})
+	bus.Publish(app.AdEvent{Type: app.EventPublished, Ad: ads.Ad{ID: 2, AuthorID: 123, Published: true}})
+	// Не опубликовано и не подходит под фильтр по умолчанию
+	bus.Publish(app.AdEvent{Type: app.EventCreated, Ad: ads.Ad{ID: 3, AuthorID: 123}})
+	// Снятие с публикации доставляется подписчикам на опубликованные
+	bus.Publish(app.AdEvent{Type: app.EventUnpublished, Ad: ads.Ad{ID: 2, AuthorID: 123}})
+
+	ev := <-sub.Events()
+	assert.Equal(t, app.EventPublished, ev.Type)
+	assert.Equal(t, int64(2), ev.Ad.ID)
+	ev = <-sub.Events()
+	assert.Equal(t, app.EventUnpublished, ev.Type)
+	assert.Equal(t, int64(2), ev.Ad.ID)
+	assert.Len(t, sub.Events(), 0)
+}
+
+func TestBusSlowConsumer(t *testing.T) {
+	bus := app.NewBus(2)
+	sub := bus.Subscribe(context.Background(), app.NewFilter(app.WithPublished(app.AnyPublished)))
+
+	for i := int64(0); i < 5; i++ {
+		bus.Publish(app.AdEvent{Type: app.EventCreated, Ad: ads.Ad{ID: i}})
+	}
+
+	var got []int64
+	for ev := range sub.Events() {
+		got = append(got, ev.Ad.ID)
+	}
+	assert.Equal(t, []int64{0, 1}, got)
+	assert.ErrorIs(t, sub.Err(), app.ErrSlowConsumer)
+}
+
+func TestBusCancel(t *testing.T) {
+	bus := app.NewBus(app.DefaultEventBuffer)
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := bus.Subscribe(ctx, app.NewFilter())
+
+	cancel()
+	select {
+	case _, ok := <-sub.Events():
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("subscription is not closed after cancel")
+	}
+	assert.ErrorIs(t, sub.Err(), context.Canceled)
+
+	// Публикация после отписки не паникует
+	bus.Publish(app.AdEvent{Type: app.EventCreated, Ad: ads.Ad{Published: true}})
+}
+
+func TestGRPCWatchAds(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name1", Email: "somemail1@mail.com", UserId: 124, Password: testPassword})
+	aCtx, bCtx := asUser(t, client, ctx, 123), asUser(t, client, ctx, 124)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	authorID := int64(123)
+	stream, err := client.WatchAds(watchCtx, &grpcPort.FilterRequest{AuthorId: &authorID, Published: grpcPort.PublishedFilter_PUBLISHED_FILTER_ALL})
+	assert.NoError(t, err)
+	// Заголовки приходят после оформления подписки
+	_, err = stream.Header()
+	assert.NoError(t, err)
+
+	ad, err := client.CreateAd(aCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text"})
+	assert.NoError(t, err)
+	_, err = client.CreateAd(bCtx, &grpcPort.CreateAdRequest{Title: "other", Text: "author"})
+	assert.NoError(t, err)
+	grpcPublishAd(t, client, ctx, aCtx, ad.Id)
+	_, err = client.UpdateAd(aCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "new title", Text: "text"})
+	assert.NoError(t, err)
+	_, err = client.ChangeAdStatus(aCtx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, Status: grpcPort.AdStatus_AD_STATUS_ARCHIVED})
+	assert.NoError(t, err)
+	_, err = client.DeleteAd(aCtx, &grpcPort.DeleteAdRequest{AdId: ad.Id})
+	assert.NoError(t, err)
+
+	expected := []grpcPort.AdEventType{
+		grpcPort.AdEventType_AD_EVENT_TYPE_CREATED,
+		// Отправка на проверку
+		grpcPort.AdEventType_AD_EVENT_TYPE_UPDATED,
+		grpcPort.AdEventType_AD_EVENT_TYPE_PUBLISHED,
+		// Изменение опубликованного объявления возвращает его на проверку
+		grpcPort.AdEventType_AD_EVENT_TYPE_UNPUBLISHED,
+		grpcPort.AdEventType_AD_EVENT_TYPE_UPDATED,
+		grpcPort.AdEventType_AD_EVENT_TYPE_DELETED,
+	}
+	for _, typ := range expected {
+		ev, err := stream.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, typ, ev.Type)
+		assert.Equal(t, ad.Id, ev.Ad.Id)
+		if typ == grpcPort.AdEventType_AD_EVENT_TYPE_UNPUBLISHED {
+			assert.Equal(t, "new title", ev.Ad.Title)
+			assert.Equal(t, grpcPort.AdStatus_AD_STATUS_PENDING, ev.Ad.Status)
+		}
+	}
+
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
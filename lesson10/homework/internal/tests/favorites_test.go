@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestFavorites(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "author", "author@mail.com")
+	_, _ = client.createUser(124, "reader", "reader@mail.com")
+	first, err := client.createAd(123, "first", "ad")
+	require.NoError(t, err)
+	second, err := client.createAd(123, "second", "ad")
+	require.NoError(t, err)
+
+	res, err := client.favorite(http.MethodPost, 124, 124, first.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, first.Data.ID, res.Data.ID)
+	_, err = client.favorite(http.MethodPost, 124, 124, second.Data.ID)
+	assert.NoError(t, err)
+	// Повторное добавление ничего не меняет
+	_, err = client.favorite(http.MethodPost, 124, 124, first.Data.ID)
+	assert.NoError(t, err)
+
+	list, err := client.listFavorites(124, 124)
+	assert.NoError(t, err)
+	require.Len(t, list.Data, 2)
+	assert.Equal(t, second.Data.ID, list.Data[0].ID)
+	assert.Equal(t, first.Data.ID, list.Data[1].ID)
+
+	_, err = client.favorite(http.MethodDelete, 124, 124, second.Data.ID)
+	assert.NoError(t, err)
+	_, err = client.favorite(http.MethodDelete, 124, 124, second.Data.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	list, err = client.listFavorites(124, 124)
+	assert.NoError(t, err)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, first.Data.ID, list.Data[0].ID)
+
+	_, err = client.favorite(http.MethodPost, 124, 124, 100500)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFavoritesAccess(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "author", "author@mail.com")
+	_, _ = client.createUser(124, "reader", "reader@mail.com")
+	ad, err := client.createAd(123, "hello", "world")
+	require.NoError(t, err)
+
+	_, err = client.favorite(http.MethodPost, 123, 124, ad.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.listFavorites(123, 124)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.listFavorites(100500, 124)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestFavoritesCleanup(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "author", "author@mail.com")
+	_, _ = client.createUser(124, "reader", "reader@mail.com")
+	_, _ = client.createUser(testAdminID, "admin", "admin@mail.com")
+	deleted, err := client.createAd(123, "deleted", "ad")
+	require.NoError(t, err)
+	kept, err := client.createAd(123, "kept", "ad")
+	require.NoError(t, err)
+	_, err = client.favorite(http.MethodPost, 124, 124, deleted.Data.ID)
+	assert.NoError(t, err)
+	_, err = client.favorite(http.MethodPost, 124, 124, kept.Data.ID)
+	assert.NoError(t, err)
+
+	_, err = client.deleteAd(123, deleted.Data.ID)
+	assert.NoError(t, err)
+	list, err := client.listFavorites(124, 124)
+	assert.NoError(t, err)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, kept.Data.ID, list.Data[0].ID)
+
+	// Восстановленное объявление в избранное не возвращается
+	_, err = client.restoreAd(testAdminID, deleted.Data.ID)
+	assert.NoError(t, err)
+	list, err = client.listFavorites(124, 124)
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+
+	// Объявления удалённого пользователя тоже пропадают из избранного
+	_, err = client.deleteUserByID(123)
+	assert.NoError(t, err)
+	list, err = client.listFavorites(124, 124)
+	assert.NoError(t, err)
+	assert.Empty(t, list.Data)
+}
+
+func TestGRPCFavorites(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "author", Email: "author@mail.com", UserId: 123, Password: testPassword})
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "reader", Email: "reader@mail.com", UserId: 124, Password: testPassword})
+	authorCtx, readerCtx := asUser(t, client, ctx, 123), asUser(t, client, ctx, 124)
+
+	ad, err := client.CreateAd(authorCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"})
+	require.NoError(t, err)
+
+	_, err = client.AddFavorite(readerCtx, &grpcPort.FavoriteRequest{UserId: 124, AdId: ad.Id})
+	assert.NoError(t, err)
+	_, err = client.ListFavorites(authorCtx, &grpcPort.ListFavoritesRequest{UserId: 124})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.ListFavorites(ctx, &grpcPort.ListFavoritesRequest{UserId: 124})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	list, err := client.ListFavorites(readerCtx, &grpcPort.ListFavoritesRequest{UserId: 124})
+	assert.NoError(t, err)
+	require.Len(t, list.List, 1)
+	assert.Equal(t, ad.Id, list.List[0].Id)
+
+	_, err = client.RemoveFavorite(readerCtx, &grpcPort.FavoriteRequest{UserId: 124, AdId: ad.Id})
+	assert.NoError(t, err)
+	_, err = client.RemoveFavorite(readerCtx, &grpcPort.FavoriteRequest{UserId: 124, AdId: ad.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+func TestFilter_Match(t *testing.T) {
+	now := time.Date(2023, 4, 20, 12, 0, 0, 0, time.UTC)
+	ad := ads.Ad{
+		ID:           1,
+		Title:        "Red Bike",
+		Text:         "Почти новый велосипед",
+		AuthorID:     123,
+		Published:    true,
+		CreationDate: now,
+		UpdateDate:   now.Add(time.Hour),
+	}
+
+	tests := []struct {
+		name     string
+		filter   app.Filter
+		expected bool
+	}{
+		{"default filter", app.NewFilter(), true},
+		{"only unpublished", app.NewFilter(app.WithPublished(app.OnlyUnpublished)), false},
+		{"any status", app.NewFilter(app.WithPublished(app.AnyPublished)), true},
+		{"same author", app.NewFilter(app.WithAuthor(123)), true},
+		{"other author", app.NewFilter(app.WithAuthor(124)), false},
+		{"created inside range", app.NewFilter(app.CreatedBetween(now, now.Add(time.Minute))), true},
+		{"created at range end", app.NewFilter(app.CreatedBetween(time.Time{}, now)), false},
+		{"updated after", app.NewFilter(app.UpdatedBetween(now.Add(2*time.Hour), time.Time{})), false},
+		{"title substring", app.NewFilter(app.TitleContains("bike")), true},
+		{"text substring", app.NewFilter(app.TextContains("ВЕЛОСИПЕД")), true},
+		{"text mismatch", app.NewFilter(app.TextContains("машина")), false},
+		{"keywords in title and text", app.NewFilter(app.WithKeywords("bike велосипед")), true},
+		{"keyword missing", app.NewFilter(app.WithKeywords("bike car")), false},
+		{"keyword is a whole word", app.NewFilter(app.WithKeywords("bik")), false},
+		{"composed", app.NewFilter(app.WithAuthor(123), app.TitleContains("red"), app.CreatedBetween(now, time.Time{})), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.filter.Match(ad))
+		})
+	}
+}
@@ -3,6 +3,7 @@ package tests
 import (
 	"context"
 	"homework10/internal/adapters/adrepo"
+	"homework10/internal/ads"
 	"strconv"
 	"testing"
 )
@@ -18,9 +19,9 @@ func FuzzMapRepo(f *testing.F) {
 		mapRepo := adrepo.New()
 		ctx := context.Background()
 		for i := int64(0); i < int64(n); i += 1 {
-			_ = mapRepo.Add(ctx, strconv.Itoa(int(i)), "text", int64(n))
+			_, _ = mapRepo.Add(ctx, strconv.Itoa(int(i)), "text", ads.Attributes{}, int64(n))
 		}
-		got := mapRepo.Add(ctx, strconv.Itoa(int(n)), "some text", 1)
+		got, _ := mapRepo.Add(ctx, strconv.Itoa(int(n)), "some text", ads.Attributes{}, 1)
 		nn := got.ID
 		expect := int64(n)
 
@@ -6,25 +6,70 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
-	"homework10/internal/adapters/adfilters"
-	"homework10/internal/adapters/userrepo"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"net"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/test/bufconn"
-	"homework10/internal/adapters/adrepo"
 	"homework10/internal/app"
+	"homework10/internal/auth"
+	"homework10/internal/metrics"
 	grpcPort "homework10/internal/ports/grpc"
 )
 
 var (
-	ErrorBadRequest = status.Error(codes.InvalidArgument, app.ErrWrongFormat.Error())
-	ErrorForbidden  = status.Error(codes.PermissionDenied, app.ErrAccessDenied.Error())
+	ErrorBadRequest      = status.Error(codes.InvalidArgument, app.ErrWrongFormat.Error())
+	ErrorForbidden       = status.Error(codes.PermissionDenied, app.ErrAccessDenied.Error())
+	ErrorUnauthenticated = status.Error(codes.Unauthenticated, auth.ErrInvalidToken.Error())
+	ErrorNotFound        = status.Error(codes.NotFound, app.ErrNotFound.Error())
+	ErrorAlreadyExists   = status.Error(codes.AlreadyExists, app.ErrAlreadyExists.Error())
 )
 
+func newGRPCServer(a app.App, logger *zap.Logger, m *metrics.Metrics) *grpc.Server {
+	tokens := auth.NewTokens([]byte("test secret"), time.Hour)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		grpcPort.TracingInterceptor,
+		grpcPort.UnaryInterceptor(logger),
+		grpcPort.MetricsInterceptor(m),
+		grpcPort.RecoveryInterceptor(logger),
+		grpcPort.AuthInterceptor(tokens),
+	), grpc.ChainStreamInterceptor(
+		grpcPort.StreamTracingInterceptor,
+		grpcPort.StreamInterceptor(logger),
+		grpcPort.StreamMetricsInterceptor(m),
+		grpcPort.StreamRecoveryInterceptor(logger),
+		grpcPort.StreamAuthInterceptor(tokens),
+	))
+	grpcPort.RegisterAdServiceServer(srv, grpcPort.NewService(a, tokens))
+	return srv
+}
+
+// asUser логинится паролем testPassword и возвращает контекст, вызовы с которым
+// выполняются от имени пользователя userID.
+func asUser(t *testing.T, client grpcPort.AdServiceClient, ctx context.Context, userID int64) context.Context {
+	t.Helper()
+	res, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: userID, Password: testPassword})
+	assert.NoError(t, err)
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+res.GetToken())
+}
+
+// grpcPublishAd отправляет объявление на проверку от имени автора и одобряет его модератором.
+func grpcPublishAd(t *testing.T, client grpcPort.AdServiceClient, ctx, authorCtx context.Context, adID int64) *grpcPort.AdResponse {
+	t.Helper()
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "moderator", Email: "moderator@mail.com", UserId: testModeratorID, Password: testPassword})
+	_, err := client.ChangeAdStatus(authorCtx, &grpcPort.ChangeAdStatusRequest{AdId: adID, Status: grpcPort.AdStatus_AD_STATUS_PENDING})
+	assert.NoError(t, err)
+	ad, err := client.ChangeAdStatus(asUser(t, client, ctx, testModeratorID), &grpcPort.ChangeAdStatusRequest{AdId: adID, Status: grpcPort.AdStatus_AD_STATUS_PUBLISHED})
+	assert.NoError(t, err)
+	return ad
+}
+
 type SuiteTest struct {
 	suite.Suite
 	client grpcPort.AdServiceClient
@@ -38,10 +83,7 @@ type SuiteTest struct {
 func (suite *SuiteTest) SetupTest() {
 	suite.lis = bufconn.Listen(1024 * 1024)
 
-	suite.srv = grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPort.UnaryInterceptor, grpcPort.RecoveryInterceptor))
-
-	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New(), adfilters.New()))
-	grpcPort.RegisterAdServiceServer(suite.srv, svc)
+	suite.srv = newGRPCServer(newTestApp(), zap.NewNop(), metrics.New())
 
 	go func() {
 		srv := suite.srv
@@ -64,19 +106,20 @@ func (suite *SuiteTest) SetupTest() {
 }
 
 func (suite *SuiteTest) TestGRPCCreateUser() {
-	res, err := suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "name", Email: "somemail@mail.com", UserId: 123})
+	res, err := suite.client.CreateUser(suite.ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
 	suite.Assert().NoError(err)
 	suite.Assert().Equal("name", res.Nickname)
 	suite.Assert().Equal("somemail@mail.com", res.Email)
 	suite.Assert().Equal(int64(123), res.UserId)
 
-	_, err = suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "name", Email: "somemail@mail.com", UserId: 123})
-	suite.Assert().ErrorIs(err, ErrorBadRequest)
+	_, err = suite.client.CreateUser(suite.ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	suite.Assert().ErrorIs(err, ErrorAlreadyExists)
 }
 
 func (suite *SuiteTest) TestGRPCCreateAd() {
-	_, _ = suite.client.CreateUser(suite.ctx, &grpcPort.UniversalUser{Nickname: "name", Email: "somemail@mail.com", UserId: 123})
-	res, err := suite.client.CreateAd(suite.ctx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", UserId: 123})
+	_, _ = suite.client.CreateUser(suite.ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	ctx := asUser(suite.T(), suite.client, suite.ctx, 123)
+	res, err := suite.client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "title", Text: "text"})
 	suite.Assert().NoError(err)
 	suite.Assert().Equal("title", res.Title)
 	suite.Assert().Equal("text", res.Text)
@@ -96,19 +139,25 @@ func TestSuite(t *testing.T) {
 }
 
 func GetTestClient(t *testing.T) (grpcPort.AdServiceClient, context.Context) {
+	return dialTestServer(t, newGRPCServer(newTestApp(), zap.NewNop(), metrics.New()))
+}
+
+func dialTestServer(t *testing.T, srv *grpc.Server) (grpcPort.AdServiceClient, context.Context) {
+	conn, ctx := dialTestConn(t, srv)
+	return grpcPort.NewAdServiceClient(conn), ctx
+}
+
+// dialTestConn запускает srv в памяти и подключается к нему; всё закрывается по завершении теста.
+func dialTestConn(t *testing.T, srv *grpc.Server) (*grpc.ClientConn, context.Context) {
 	lis := bufconn.Listen(1024 * 1024)
 	t.Cleanup(func() {
 		lis.Close()
 	})
 
-	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPort.UnaryInterceptor, grpcPort.RecoveryInterceptor))
 	t.Cleanup(func() {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New(), adfilters.New()))
-	grpcPort.RegisterAdServiceServer(srv, svc)
-
 	go func() {
 		assert.NoError(t, srv.Serve(lis), "srv.Serve")
 	}()
@@ -129,74 +178,77 @@ func GetTestClient(t *testing.T) (grpcPort.AdServiceClient, context.Context) {
 		conn.Close()
 	})
 
-	client := grpcPort.NewAdServiceClient(conn)
-	return client, ctx
+	return conn, ctx
 }
 
 func TestGRPCCreateUser(t *testing.T) {
 	client, ctx := GetTestClient(t)
 
-	res, err := client.CreateUser(ctx, &grpcPort.UniversalUser{Nickname: "name", Email: "somemail@mail.com", UserId: 123})
+	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
 	assert.NoError(t, err)
 	assert.Equal(t, "name", res.Nickname)
 	assert.Equal(t, "somemail@mail.com", res.Email)
 	assert.Equal(t, int64(123), res.UserId)
 
-	_, err = client.CreateUser(ctx, &grpcPort.UniversalUser{Nickname: "abc", Email: "cat@mail.com", UserId: 123})
-	assert.ErrorIs(t, err, ErrorBadRequest)
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "abc", Email: "cat@mail.com", UserId: 123, Password: testPassword})
+	assert.ErrorIs(t, err, ErrorAlreadyExists)
 }
 
 func TestGRPCCreateAd(t *testing.T) {
 	client, ctx := GetTestClient(t)
 
-	a, err := client.CreateUser(ctx, &grpcPort.UniversalUser{Nickname: "name", Email: "somemail@mail.com", UserId: 123})
+	a, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
 	assert.NoError(t, err)
 
-	res, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", UserId: a.UserId})
+	res, err := client.CreateAd(asUser(t, client, ctx, a.UserId), &grpcPort.CreateAdRequest{Title: "title", Text: "text"})
 	assert.NoError(t, err)
 	assert.Equal(t, "title", res.Title)
 	assert.Equal(t, "text", res.Text)
 	assert.Equal(t, a.UserId, res.AuthorId)
 	assert.Equal(t, false, res.Published)
 
-	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "cat", Text: "text", UserId: 5})
-	assert.ErrorIs(t, err, ErrorBadRequest)
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "cat", Text: "text"})
+	assert.ErrorIs(t, err, ErrorUnauthenticated)
 }
 
 func TestGRPCChangeAdStatus(t *testing.T) {
 	client, ctx := GetTestClient(t)
 
-	a, _ := client.CreateUser(ctx, &grpcPort.UniversalUser{Nickname: "name", Email: "somemail@mail.com", UserId: 123})
-	b, _ := client.CreateUser(ctx, &grpcPort.UniversalUser{Nickname: "name", Email: "somemail@mail.com", UserId: 124})
-	ad, _ := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", UserId: a.UserId})
+	a, _ := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	b, _ := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name1", Email: "somemail1@mail.com", UserId: 124, Password: testPassword})
+	aCtx, bCtx := asUser(t, client, ctx, a.UserId), asUser(t, client, ctx, b.UserId)
+	ad, _ := client.CreateAd(aCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text"})
 
-	updatedAd, err := client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, UserId: ad.AuthorId, Published: true})
-	assert.NoError(t, err)
+	assert.Equal(t, grpcPort.AdStatus_AD_STATUS_DRAFT, ad.Status)
+
+	updatedAd := grpcPublishAd(t, client, ctx, aCtx, ad.Id)
 	assert.Equal(t, ad.Title, updatedAd.Title)
 	assert.Equal(t, ad.Text, updatedAd.Text)
 	assert.Equal(t, ad.AuthorId, updatedAd.AuthorId)
 	assert.Equal(t, true, updatedAd.Published)
+	assert.Equal(t, grpcPort.AdStatus_AD_STATUS_PUBLISHED, updatedAd.Status)
 
-	add, _ := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "ti", Text: "te", UserId: a.UserId})
-	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, UserId: b.UserId, Published: add.Published})
+	add, _ := client.CreateAd(aCtx, &grpcPort.CreateAdRequest{Title: "ti", Text: "te"})
+	_, err := client.ChangeAdStatus(bCtx, &grpcPort.ChangeAdStatusRequest{AdId: add.Id, Status: grpcPort.AdStatus_AD_STATUS_PENDING})
 	assert.ErrorIs(t, err, ErrorForbidden)
 }
 
 func TestGRPCUpdateAd(t *testing.T) {
 	client, ctx := GetTestClient(t)
 
-	a, _ := client.CreateUser(ctx, &grpcPort.UniversalUser{Nickname: "name", Email: "somemail@mail.com", UserId: 123})
-	b, _ := client.CreateUser(ctx, &grpcPort.UniversalUser{Nickname: "name1", Email: "1@mail.com", UserId: 5})
-	ad, _ := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", UserId: a.UserId})
+	a, _ := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	b, _ := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name1", Email: "1@mail.com", UserId: 5, Password: testPassword})
+	aCtx, bCtx := asUser(t, client, ctx, a.UserId), asUser(t, client, ctx, b.UserId)
+	ad, _ := client.CreateAd(aCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text"})
 
-	updatedAd, err := client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "title1", Text: "text1", UserId: ad.AuthorId})
+	updatedAd, err := client.UpdateAd(aCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "title1", Text: "text1"})
 	assert.NoError(t, err)
 	assert.Equal(t, "title1", updatedAd.Title)
 	assert.Equal(t, "text1", updatedAd.Text)
 	assert.Equal(t, ad.AuthorId, updatedAd.AuthorId)
 	assert.Equal(t, ad.Published, updatedAd.Published)
 
-	_, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "new title", Text: "new text", UserId: b.UserId})
+	_, err = client.UpdateAd(bCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "new title", Text: "new text"})
 	assert.ErrorIs(t, err, ErrorForbidden)
 
 }
@@ -204,15 +256,18 @@ func TestGRPCUpdateAd(t *testing.T) {
 func TestGRPCDeleteAd(t *testing.T) {
 	client, ctx := GetTestClient(t)
 
-	a, _ := client.CreateUser(ctx, &grpcPort.UniversalUser{Nickname: "name", Email: "somemail@mail.com", UserId: 123})
-	b, _ := client.CreateUser(ctx, &grpcPort.UniversalUser{Nickname: "name1", Email: "somemail1@mail.com", UserId: 124})
-	ad, _ := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", UserId: a.UserId})
-	_, err := client.DeleteAd(ctx, &grpcPort.DeleteAdRequest{AdId: ad.Id, AuthorId: b.UserId})
+	a, _ := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	b, _ := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name1", Email: "somemail1@mail.com", UserId: 124, Password: testPassword})
+	aCtx, bCtx := asUser(t, client, ctx, a.UserId), asUser(t, client, ctx, b.UserId)
+	ad, _ := client.CreateAd(aCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text"})
+	_, err := client.DeleteAd(bCtx, &grpcPort.DeleteAdRequest{AdId: ad.Id})
 	assert.ErrorIs(t, err, ErrorForbidden)
-	_, err = client.DeleteAd(ctx, &grpcPort.DeleteAdRequest{AdId: ad.Id + 1, AuthorId: ad.AuthorId})
-	assert.ErrorIs(t, err, ErrorBadRequest)
+	_, err = client.DeleteAd(aCtx, &grpcPort.DeleteAdRequest{AdId: ad.Id + 1})
+	assert.ErrorIs(t, err, ErrorNotFound)
+	_, err = client.DeleteAd(ctx, &grpcPort.DeleteAdRequest{AdId: ad.Id})
+	assert.ErrorIs(t, err, ErrorUnauthenticated)
 
-	resp, err := client.DeleteAd(ctx, &grpcPort.DeleteAdRequest{AdId: ad.Id, AuthorId: ad.AuthorId})
+	resp, err := client.DeleteAd(aCtx, &grpcPort.DeleteAdRequest{AdId: ad.Id})
 	assert.NoError(t, err)
 	assert.Equal(t, ad.Title, resp.Title)
 	assert.Equal(t, ad.Text, resp.Text)
@@ -223,12 +278,17 @@ func TestGRPCDeleteAd(t *testing.T) {
 func TestGRPCDeleteUserByID(t *testing.T) {
 	client, ctx := GetTestClient(t)
 
-	a, _ := client.CreateUser(ctx, &grpcPort.UniversalUser{Nickname: "name", Email: "somemail@mail.com", UserId: 123})
+	a, _ := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
 
-	_, err := client.DeleteUserByID(ctx, &grpcPort.DeleteUserRequest{Id: a.UserId + 1})
-	assert.ErrorIs(t, err, ErrorBadRequest)
+	aCtx := asUser(t, client, ctx, a.UserId)
+
+	_, err := client.DeleteUserByID(ctx, &grpcPort.DeleteUserRequest{Id: a.UserId})
+	assert.ErrorIs(t, err, ErrorUnauthenticated)
+
+	_, err = client.DeleteUserByID(aCtx, &grpcPort.DeleteUserRequest{Id: a.UserId + 1})
+	assert.ErrorIs(t, err, ErrorForbidden)
 
-	resp, err := client.DeleteUserByID(ctx, &grpcPort.DeleteUserRequest{Id: a.UserId})
+	resp, err := client.DeleteUserByID(aCtx, &grpcPort.DeleteUserRequest{Id: a.UserId})
 	assert.NoError(t, err)
 	assert.Equal(t, a.Nickname, resp.Nickname)
 	assert.Equal(t, a.Email, resp.Email)
@@ -238,15 +298,16 @@ func TestGRPCDeleteUserByID(t *testing.T) {
 func TestGRPCDefaultFilter(t *testing.T) {
 	client, ctx := GetTestClient(t)
 
-	_, _ = client.CreateUser(ctx, &grpcPort.UniversalUser{Nickname: "name", Email: "somemail@mail.com", UserId: 123})
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
 
-	resp, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: 123, Title: "hello", Text: "world"})
-	assert.NoError(t, err)
+	userCtx := asUser(t, client, ctx, 123)
 
-	publishedAd, err := client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{UserId: 123, AdId: resp.Id, Published: true})
+	resp, err := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"})
 	assert.NoError(t, err)
 
-	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: 123, Title: "hello", Text: "world"})
+	publishedAd := grpcPublishAd(t, client, ctx, userCtx, resp.Id)
+
+	_, err = client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"})
 	assert.NoError(t, err)
 
 	ads, err := client.ListAds(ctx, &grpcPort.FilterRequest{})
@@ -258,3 +319,183 @@ func TestGRPCDefaultFilter(t *testing.T) {
 	assert.Equal(t, ads.List[0].AuthorId, publishedAd.AuthorId)
 	assert.True(t, ads.List[0].Published)
 }
+
+func TestGRPCListAdsFilter(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name1", Email: "somemail1@mail.com", UserId: 124, Password: testPassword})
+
+	a, err := client.CreateAd(asUser(t, client, ctx, 123), &grpcPort.CreateAdRequest{Title: "Red bike", Text: "world"})
+	assert.NoError(t, err)
+	bCtx := asUser(t, client, ctx, 124)
+	b, err := client.CreateAd(bCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"})
+	assert.NoError(t, err)
+	grpcPublishAd(t, client, ctx, bCtx, b.Id)
+
+	authorID := int64(123)
+	ads, err := client.ListAds(ctx, &grpcPort.FilterRequest{AuthorId: &authorID, Published: grpcPort.PublishedFilter_PUBLISHED_FILTER_ALL})
+	assert.NoError(t, err)
+	assert.Len(t, ads.List, 1)
+	assert.Equal(t, a.Id, ads.List[0].Id)
+
+	ads, err = client.ListAds(ctx, &grpcPort.FilterRequest{Published: grpcPort.PublishedFilter_PUBLISHED_FILTER_UNPUBLISHED, Title: "bike"})
+	assert.NoError(t, err)
+	assert.Len(t, ads.List, 1)
+	assert.Equal(t, a.Id, ads.List[0].Id)
+
+	ads, err = client.ListAds(ctx, &grpcPort.FilterRequest{CreatedFrom: timestamppb.New(time.Now().Add(time.Hour))})
+	assert.NoError(t, err)
+	assert.Len(t, ads.List, 0)
+}
+
+func TestGRPCListAdsPagination(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	userCtx := asUser(t, client, ctx, 123)
+	for i := 0; i < 5; i++ {
+		ad, err := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"})
+		assert.NoError(t, err)
+		grpcPublishAd(t, client, ctx, userCtx, ad.Id)
+	}
+
+	req := &grpcPort.FilterRequest{PageSize: 3, SortBy: grpcPort.SortBy_SORT_BY_CREATION_DATE}
+	first, err := client.ListAds(ctx, req)
+	assert.NoError(t, err)
+	assert.Len(t, first.List, 3)
+	assert.NotEmpty(t, first.NextPageToken)
+
+	req.PageToken = first.NextPageToken
+	second, err := client.ListAds(ctx, req)
+	assert.NoError(t, err)
+	assert.Len(t, second.List, 2)
+	assert.Empty(t, second.NextPageToken)
+	assert.Equal(t, []int64{3, 4}, []int64{second.List[0].Id, second.List[1].Id})
+
+	req.SortBy = grpcPort.SortBy_SORT_BY_TITLE
+	_, err = client.ListAds(ctx, req)
+	assert.ErrorIs(t, err, ErrorBadRequest)
+}
+
+func TestGRPCLogin(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: "short"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	assert.NoError(t, err)
+
+	res, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: 123, Password: testPassword})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, res.Token)
+
+	_, err = client.Login(ctx, &grpcPort.LoginRequest{UserId: 123, Password: "wrong password"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.Login(ctx, &grpcPort.LoginRequest{UserId: 124, Password: testPassword})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	badCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+res.Token+"x")
+	_, err = client.CreateAd(badCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text"})
+	assert.ErrorIs(t, err, ErrorUnauthenticated)
+}
+
+func TestGRPCGetAd(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	userCtx := asUser(t, client, ctx, 123)
+	ad, err := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text"})
+	assert.NoError(t, err)
+	updated, err := client.UpdateAd(userCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "new title", Text: "new text"})
+	assert.NoError(t, err)
+
+	res, err := client.GetAd(ctx, &grpcPort.GetAdRequest{Id: ad.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, "new title", res.Title)
+	assert.Equal(t, "new text", res.Text)
+	assert.Equal(t, int64(123), res.AuthorId)
+	assert.Equal(t, updated.UpdateDate.AsTime(), res.UpdateDate.AsTime())
+	assert.False(t, res.UpdateDate.AsTime().Before(res.CreationDate.AsTime()))
+
+	_, err = client.GetAd(ctx, &grpcPort.GetAdRequest{Id: ad.Id + 1})
+	assert.ErrorIs(t, err, ErrorNotFound)
+}
+
+func TestGRPCGetUser(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	a, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	assert.NoError(t, err)
+
+	// Без токена профиль приходит без email, как и в HTTP API
+	res, err := client.GetUser(ctx, &grpcPort.GetUserRequest{Id: a.UserId})
+	assert.NoError(t, err)
+	assert.Equal(t, a.UserId, res.UserId)
+	assert.Equal(t, a.Nickname, res.Nickname)
+	assert.Empty(t, res.Email)
+
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "other", Email: "other@mail.com", UserId: 200, Password: testPassword})
+	require.NoError(t, err)
+	res, err = client.GetUser(asUser(t, client, ctx, 200), &grpcPort.GetUserRequest{Id: a.UserId})
+	assert.NoError(t, err)
+	assert.Empty(t, res.Email)
+
+	res, err = client.GetUser(asUser(t, client, ctx, 123), &grpcPort.GetUserRequest{Id: a.UserId})
+	assert.NoError(t, err)
+	assert.Equal(t, a.Email, res.Email)
+
+	_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: a.UserId + 1})
+	assert.ErrorIs(t, err, ErrorNotFound)
+}
+
+func TestGRPCChangeUserInfo(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	a, _ := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	b, _ := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name1", Email: "somemail1@mail.com", UserId: 124, Password: testPassword})
+	aCtx := asUser(t, client, ctx, a.UserId)
+
+	res, err := client.ChangeUserInfo(aCtx, &grpcPort.ChangeUserInfoRequest{UserId: a.UserId, Nickname: "namenew", Email: "somemailnew@mail.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, a.UserId, res.UserId)
+	assert.Equal(t, "namenew", res.Nickname)
+	assert.Equal(t, "somemailnew@mail.com", res.Email)
+
+	got, err := client.GetUser(ctx, &grpcPort.GetUserRequest{Id: a.UserId})
+	assert.NoError(t, err)
+	assert.Equal(t, "namenew", got.Nickname)
+
+	_, err = client.ChangeUserInfo(aCtx, &grpcPort.ChangeUserInfoRequest{UserId: b.UserId, Nickname: "hacker", Email: "hacker@mail.com"})
+	assert.ErrorIs(t, err, ErrorForbidden)
+
+	_, err = client.ChangeUserInfo(ctx, &grpcPort.ChangeUserInfoRequest{UserId: a.UserId, Nickname: "name", Email: "somemail@mail.com"})
+	assert.ErrorIs(t, err, ErrorUnauthenticated)
+}
+
+func TestGRPCGetAdsByTitle(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	userCtx := asUser(t, client, ctx, 123)
+	a, _ := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text"})
+	b, _ := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text"})
+	_, _ = client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "text", Text: "title"})
+
+	res, err := client.GetAdsByTitle(ctx, &grpcPort.GetAdsByTitleRequest{Title: "title"})
+	assert.NoError(t, err)
+	assert.Len(t, res.List, 2)
+	assert.Equal(t, []int64{a.Id, b.Id}, []int64{res.List[0].Id, res.List[1].Id})
+
+	first, err := client.GetAdsByTitle(ctx, &grpcPort.GetAdsByTitleRequest{Title: "title", PageSize: 1, Desc: true})
+	assert.NoError(t, err)
+	assert.Len(t, first.List, 1)
+	assert.Equal(t, b.Id, first.List[0].Id)
+
+	second, err := client.GetAdsByTitle(ctx, &grpcPort.GetAdsByTitleRequest{Title: "title", PageSize: 1, Desc: true, PageToken: first.NextPageToken})
+	assert.NoError(t, err)
+	assert.Len(t, second.List, 1)
+	assert.Equal(t, a.Id, second.List[0].Id)
+	assert.Empty(t, second.NextPageToken)
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/health"
+	"homework10/internal/metrics"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func getHealth(t *testing.T, url string) (int, healthResponse) {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var res healthResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	return resp.StatusCode, res
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	var storageErr error
+	checker := health.New()
+	checker.AddCheck("storage", func(ctx context.Context) error { return storageErr })
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", checker.Healthz())
+	mux.Handle("/readyz", checker.Readyz())
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		code, res := getHealth(t, srv.URL+path)
+		assert.Equal(t, http.StatusOK, code, path)
+		assert.Equal(t, "ok", res.Status, path)
+	}
+
+	// Недоступное хранилище делает сервис неготовым, но не мёртвым: перезапуск не поможет
+	storageErr = errors.New("connection refused")
+	code, res := getHealth(t, srv.URL+"/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", res.Status)
+	code, res = getHealth(t, srv.URL+"/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"storage": "connection refused"}, res.Checks)
+
+	// После начала остановки сервис жив, но не готов
+	storageErr = nil
+	checker.Shutdown()
+	code, _ = getHealth(t, srv.URL+"/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	code, res = getHealth(t, srv.URL+"/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "shutting down", res.Status)
+}
+
+func TestGRPCHealth(t *testing.T) {
+	var storageErr error
+	checker := health.New()
+	checker.AddCheck("storage", func(ctx context.Context) error { return storageErr })
+	srv := newGRPCServer(newTestApp(), zap.NewNop(), metrics.New())
+	checker.RegisterGRPC(srv, grpcPort.AdService_ServiceDesc.ServiceName)
+	conn, ctx := dialTestConn(t, srv)
+	client := healthpb.NewHealthClient(conn)
+
+	// Проверка состояния доступна без токена
+	for _, service := range []string{"", grpcPort.AdService_ServiceDesc.ServiceName} {
+		res, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status, service)
+	}
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown.Service"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	storageErr = errors.New("connection refused")
+	res, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, res.Status)
+
+	storageErr = nil
+	watch, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	update, err := watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, update.Status)
+
+	checker.Shutdown()
+	update, err = watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, update.Status)
+	res, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, res.Status)
+}
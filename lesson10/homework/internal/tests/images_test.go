@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/blobfs"
+	"homework10/internal/adapters/chatrepo"
+	"homework10/internal/adapters/favrepo"
+	"homework10/internal/adapters/userrepo"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, h/2, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestUploadImage(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	_, _ = client.createUser(124, "other", "other@mail.com")
+	ad, err := client.createAd(123, "hello", "world")
+	assert.NoError(t, err)
+
+	data := testPNG(t, 800, 400)
+	res, err := client.uploadImage(123, ad.Data.ID, data)
+	require.NoError(t, err)
+	require.Len(t, res.Data.Images, 1)
+	img := res.Data.Images[0]
+	assert.Equal(t, "image/png", img.ContentType)
+	assert.Equal(t, int64(len(data)), img.Size)
+	assert.Equal(t, 800, img.Width)
+	assert.Equal(t, 400, img.Height)
+	assert.Equal(t, ad.Data.Version+1, res.Data.Version)
+
+	got, contentType, err := client.getFile(img.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	assert.Equal(t, data, got)
+
+	thumb, contentType, err := client.getFile(img.ThumbnailURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", contentType)
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb))
+	assert.NoError(t, err)
+	assert.Equal(t, app.ThumbnailSize, cfg.Width)
+	assert.Equal(t, app.ThumbnailSize/2, cfg.Height)
+
+	_, err = client.uploadImage(124, ad.Data.ID, data)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, _, err = client.getFile(strings.Replace(img.URL, img.ID, "unknown", 1))
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestUploadImageValidation(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	ad, err := client.createAd(123, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.uploadImage(123, ad.Data.ID, []byte("definitely not an image"))
+	assert.ErrorIs(t, err, ErrUnsupportedMedia)
+
+	// Подпись PNG без самого изображения
+	_, err = client.uploadImage(123, ad.Data.ID, []byte("\x89PNG\r\n\x1a\n broken"))
+	assert.ErrorIs(t, err, ErrUnsupportedMedia)
+
+	large := append(testPNG(t, 1, 1), make([]byte, app.MaxImageSize)...)
+	_, err = client.uploadImage(123, ad.Data.ID, large)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	small := testPNG(t, 10, 10)
+	for i := 0; i < app.MaxImagesPerAd; i++ {
+		_, err = client.uploadImage(123, ad.Data.ID, small)
+		assert.NoError(t, err)
+	}
+	_, err = client.uploadImage(123, ad.Data.ID, small)
+	assert.ErrorIs(t, err, ErrUnprocessable)
+}
+
+func TestPurgeDeletesImages(t *testing.T) {
+	ctx := context.Background()
+	blobs, err := blobfs.New(t.TempDir())
+	require.NoError(t, err)
+	a := app.NewApp(adrepo.New(), userrepo.New(), favrepo.New(), chatrepo.New(), app.WithBlobStore(blobs), app.WithUserImport())
+
+	_, err = a.CreateUser(ctx, "nickname", "somemail@mail.ru", testPassword, 123)
+	assert.NoError(t, err)
+	userCtx := app.WithUser(ctx, 123)
+	ad, err := a.CreateAd(userCtx, "title", "text", ads.Attributes{})
+	assert.NoError(t, err)
+	ad, err = a.AddAdImage(userCtx, ad.ID, bytes.NewReader(testPNG(t, 10, 10)))
+	require.NoError(t, err)
+	img := ad.Images[0]
+
+	_, err = a.DeleteAd(userCtx, ad.ID, app.AnyVersion)
+	assert.NoError(t, err)
+	_, err = a.PurgeDeleted(ctx, time.Now().UTC().Add(time.Second))
+	assert.NoError(t, err)
+
+	_, err = blobs.Get(ctx, img.Key)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+	_, err = blobs.Get(ctx, img.ThumbnailKey)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+}
+
+func TestBlobStore(t *testing.T) {
+	ctx := context.Background()
+	blobs, err := blobfs.New(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, blobs.Put(ctx, "ads/1/a", strings.NewReader("content")))
+	r, err := blobs.Get(ctx, "ads/1/a")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(r)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, "content", buf.String())
+
+	assert.NoError(t, blobs.Delete(ctx, "ads/1/a"))
+	assert.NoError(t, blobs.Delete(ctx, "ads/1/a"))
+	_, err = blobs.Get(ctx, "ads/1/a")
+	assert.ErrorIs(t, err, app.ErrNotFound)
+
+	for _, key := range []string{"", "../a", "ads/../../a", "/etc/passwd", "ads//a"} {
+		assert.Error(t, blobs.Put(ctx, key, strings.NewReader("x")), key)
+	}
+}
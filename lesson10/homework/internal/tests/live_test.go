@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type liveMessage struct {
+	Type  string `json:"type"`
+	Ad    adData `json:"ad"`
+	Error string `json:"error"`
+}
+
+// liveConn дочитывает данные, которые ws.Dial успел прочитать вместе с ответом на рукопожатие.
+type liveConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c liveConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func dialLive(t *testing.T, tc *testClient) net.Conn {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	conn, br, _, err := ws.Dial(ctx, "ws://"+strings.TrimPrefix(tc.baseURL, "http://")+"/api/v1/ads/live")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	if br == nil {
+		return conn
+	}
+	return liveConn{Conn: conn, r: io.MultiReader(br, conn)}
+}
+
+func readLive(t *testing.T, conn net.Conn) liveMessage {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	data, err := wsutil.ReadServerText(conn)
+	require.NoError(t, err)
+
+	var msg liveMessage
+	require.NoError(t, json.Unmarshal(data, &msg))
+	return msg
+}
+
+func TestLiveAds(t *testing.T) {
+	client := getTestClient()
+	_, err := client.createUser(123, "name", "somemail@mail.com")
+	assert.NoError(t, err)
+	_, err = client.createUser(124, "name1", "somemail1@mail.com")
+	assert.NoError(t, err)
+
+	conn := dialLive(t, client)
+	assert.Equal(t, "subscribed", readLive(t, conn).Type)
+
+	require.NoError(t, wsutil.WriteClientText(conn, []byte(`{"author_id": 123, "keywords": "bike"}`)))
+	assert.Equal(t, "subscribed", readLive(t, conn).Type)
+
+	other, err := client.createAd(124, "Red bike", "fast")
+	assert.NoError(t, err)
+	_, err = client.publishAd(124, other.Data.ID)
+	assert.NoError(t, err)
+	car, err := client.createAd(123, "Red car", "fast")
+	assert.NoError(t, err)
+	_, err = client.publishAd(123, car.Data.ID)
+	assert.NoError(t, err)
+	bike, err := client.createAd(123, "Blue bike", "slow")
+	assert.NoError(t, err)
+	_, err = client.publishAd(123, bike.Data.ID)
+	assert.NoError(t, err)
+
+	// Создание неопубликованного объявления и чужие объявления в ленту не попадают
+	msg := readLive(t, conn)
+	assert.Equal(t, "published", msg.Type)
+	assert.Equal(t, bike.Data.ID, msg.Ad.ID)
+	assert.Equal(t, "Blue bike", msg.Ad.Title)
+
+	_, err = client.changeAdStatus(123, bike.Data.ID, "archived", "")
+	assert.NoError(t, err)
+	msg = readLive(t, conn)
+	assert.Equal(t, "unpublished", msg.Type)
+	assert.Equal(t, bike.Data.ID, msg.Ad.ID)
+}
+
+func TestLiveAdsInvalidFilter(t *testing.T) {
+	client := getTestClient()
+	conn := dialLive(t, client)
+	assert.Equal(t, "subscribed", readLive(t, conn).Type)
+
+	require.NoError(t, wsutil.WriteClientText(conn, []byte(`{"author_id": "123"`)))
+	msg := readLive(t, conn)
+	assert.Equal(t, "error", msg.Type)
+	assert.NotEmpty(t, msg.Error)
+
+	// Соединение остаётся открытым, фильтр можно прислать заново
+	require.NoError(t, wsutil.WriteClientText(conn, []byte(`{"author_id": 123}`)))
+	assert.Equal(t, "subscribed", readLive(t, conn).Type)
+}
@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/app"
+	"homework10/internal/metrics"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestHTTPAccessLog(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(core)
+	client := newTestClient(newTestAppWith(app.WithUserImport(), app.WithLogger(logger)), logger, metrics.New())
+	_, err := client.createUser(123, "user", "user@mail.com")
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, client.baseURL+"/api/v1/ads", bytes.NewBufferString(`{"title":"hello","text":"world"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "req-1")
+	client.authorize(req, 123)
+	var ad adResponse
+	header, err := client.getResponseHeader(req, &ad)
+	require.NoError(t, err)
+	assert.Equal(t, "req-1", header.Get("X-Request-ID"))
+
+	entries := logs.FilterMessage("http request").FilterField(zap.String("request_id", "req-1")).All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "POST", fields["method"])
+	assert.Equal(t, "/api/v1/ads", fields["path"])
+	assert.Equal(t, int64(http.StatusOK), fields["status"])
+	assert.Equal(t, int64(123), fields["user_id"])
+
+	// Записи приложения попадают в журнал с тем же ID запроса
+	events := logs.FilterMessage("ad event").FilterField(zap.String("request_id", "req-1")).All()
+	require.Len(t, events, 1)
+	assert.Equal(t, ad.Data.ID, events[0].ContextMap()["ad_id"])
+
+	// Неподходящий ID заменяется новым
+	req, err = http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/users/123", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Request-ID", "bad id!")
+	var user userResponse
+	header, err = client.getResponseHeader(req, &user)
+	require.NoError(t, err)
+	id := header.Get("X-Request-ID")
+	assert.Len(t, id, 32)
+	entries = logs.FilterMessage("http request").FilterField(zap.String("request_id", id)).All()
+	require.Len(t, entries, 1)
+	assert.NotContains(t, entries[0].ContextMap(), "user_id")
+}
+
+func TestGRPCAccessLog(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+	client, ctx := dialTestServer(t, newGRPCServer(newTestAppWith(app.WithUserImport(), app.WithLogger(logger)), logger, metrics.New()))
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "user", Email: "user@mail.com", UserId: 123, Password: testPassword})
+	require.NoError(t, err)
+	userCtx := metadata.AppendToOutgoingContext(asUser(t, client, ctx, 123), "x-request-id", "grpc-req-1")
+
+	var header metadata.MD
+	_, err = client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"grpc-req-1"}, header.Get("x-request-id"))
+
+	entries := logs.FilterMessage("grpc call").FilterField(zap.String("request_id", "grpc-req-1")).All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.True(t, strings.HasSuffix(fields["method"].(string), "/CreateAd"))
+	assert.Equal(t, codes.OK.String(), fields["code"])
+	assert.Equal(t, int64(123), fields["user_id"])
+
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"}, grpc.Header(&header))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	id := header.Get("x-request-id")
+	require.Len(t, id, 1)
+	entries = logs.FilterMessage("grpc call").FilterField(zap.String("request_id", id[0])).All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, codes.Unauthenticated.String(), entries[0].ContextMap()["code"])
+	assert.NotContains(t, entries[0].ContextMap(), "user_id")
+
+	// Публичный поток с токеном, как и публичный вызов, выполняется от имени пользователя
+	watchCtx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(asUser(t, client, ctx, 123), "x-request-id", "grpc-req-2"))
+	stream, err := client.WatchAds(watchCtx, &grpcPort.FilterRequest{})
+	require.NoError(t, err)
+	_, err = stream.Header()
+	require.NoError(t, err)
+	cancel()
+	require.Eventually(t, func() bool {
+		return logs.FilterMessage("grpc stream").FilterField(zap.String("request_id", "grpc-req-2")).Len() == 1
+	}, time.Second, 10*time.Millisecond)
+	entries = logs.FilterMessage("grpc stream").FilterField(zap.String("request_id", "grpc-req-2")).All()
+	assert.Equal(t, int64(123), entries[0].ContextMap()["user_id"])
+}
+
+// panickingApp падает при выдаче объявлений.
+type panickingApp struct {
+	app.App
+}
+
+func (panickingApp) GetAllAdsByFilter(context.Context, app.Filter, app.Pagination) (app.AdsPage, error) {
+	panic("storage is broken")
+}
+
+func (panickingApp) WatchAds(context.Context, app.Filter) *app.Subscription {
+	panic("storage is broken")
+}
+
+func TestGRPCRecovery(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	client, ctx := dialTestServer(t, newGRPCServer(panickingApp{App: newTestApp()}, zap.New(core), metrics.New()))
+
+	// Клиент не видит ни стека, ни текста паники: они остаются в журнале
+	_, err := client.ListAds(ctx, &grpcPort.FilterRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "internal error", status.Convert(err).Message())
+
+	stream, err := client.WatchAds(ctx, &grpcPort.FilterRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "internal error", status.Convert(err).Message())
+
+	entries := logs.FilterMessage("grpc handler panicked").All()
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		fields := entry.ContextMap()
+		assert.Equal(t, "storage is broken", fields["panic"])
+		assert.Contains(t, fields["stack"], "goroutine")
+		assert.NotEmpty(t, fields["request_id"])
+	}
+}
+
+// brokenApp отвечает ошибкой хранилища, текст которой не должен дойти до клиента.
+type brokenApp struct {
+	app.App
+}
+
+func (brokenApp) GetAllAdsByFilter(context.Context, app.Filter, app.Pagination) (app.AdsPage, error) {
+	return app.AdsPage{}, errors.New(`pq: relation "ads" does not exist`)
+}
+
+func TestInternalErrorHidden(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+
+	client := newTestClient(brokenApp{App: newTestApp()}, logger, metrics.New())
+	resp, err := http.Get(client.baseURL + "/api/v1/ads")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Contains(t, string(body), "internal error")
+	assert.NotContains(t, string(body), "relation")
+
+	// Полный текст ошибки остаётся в журнале запроса
+	entries := logs.FilterMessage("http request").FilterField(zap.String("path", "/api/v1/ads")).All()
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0].ContextMap()["error"], `relation "ads"`)
+
+	grpcClient, ctx := dialTestServer(t, newGRPCServer(brokenApp{App: newTestApp()}, logger, metrics.New()))
+	_, err = grpcClient.ListAds(ctx, &grpcPort.FilterRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "internal error", status.Convert(err).Message())
+
+	entries = logs.FilterMessage("grpc call").All()
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0].ContextMap()["error"], `relation "ads"`)
+}
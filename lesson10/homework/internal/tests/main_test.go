@@ -0,0 +1,12 @@
+package tests
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	_ = os.RemoveAll(testBlobDir)
+	os.Exit(code)
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/ads"
+	"homework10/internal/metrics"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+// scrape забирает показатели так же, как Prometheus, и разбирает ответ.
+func scrape(t *testing.T, m *metrics.Metrics) map[string]*dto.MetricFamily {
+	t.Helper()
+	srv := httptest.NewServer(m.Handler())
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	require.NoError(t, err)
+	return families
+}
+
+// metricValue возвращает значение ряда с метками labels: для гистограммы - число наблюдений.
+func metricValue(t *testing.T, families map[string]*dto.MetricFamily, name string, labels map[string]string) float64 {
+	t.Helper()
+	family, ok := families[name]
+	require.True(t, ok, "no metric %s", name)
+	for _, metric := range family.GetMetric() {
+		matched := 0
+		for _, pair := range metric.GetLabel() {
+			if v, ok := labels[pair.GetName()]; ok && v == pair.GetValue() {
+				matched++
+			}
+		}
+		if matched != len(labels) {
+			continue
+		}
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			return metric.GetCounter().GetValue()
+		case dto.MetricType_GAUGE:
+			return metric.GetGauge().GetValue()
+		case dto.MetricType_HISTOGRAM:
+			return float64(metric.GetHistogram().GetSampleCount())
+		}
+	}
+	require.Failf(t, "no series", "%s%v", name, labels)
+	return 0
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	a := newTestApp()
+	m := metrics.New()
+	m.RegisterApp(a)
+	client := newTestClient(a, zap.NewNop(), m)
+
+	_, err := client.createUser(123, "user", "user@mail.com")
+	require.NoError(t, err)
+	_, err = client.createUser(124, "other", "other@mail.com")
+	require.NoError(t, err)
+	ad, err := client.createAd(123, "hello", "world")
+	require.NoError(t, err)
+	_, err = client.createAd(123, "bike", "fast one")
+	require.NoError(t, err)
+	_, err = client.deleteAd(123, ad.Data.ID)
+	require.NoError(t, err)
+
+	_, err = client.getUser(123)
+	require.NoError(t, err)
+	_, err = client.getUser(123)
+	require.NoError(t, err)
+	_, err = client.getUser(999)
+	assert.ErrorIs(t, err, ErrNotFound)
+	resp, err := client.client.Get(client.baseURL + "/api/v1/unknown/1")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	families := scrape(t, m)
+	route := map[string]string{"method": "GET", "route": "/api/v1/users/:user_id"}
+	assert.Equal(t, 2.0, metricValue(t, families, "ads_http_requests_total", map[string]string{"method": "GET", "route": "/api/v1/users/:user_id", "status": "200"}))
+	assert.Equal(t, 1.0, metricValue(t, families, "ads_http_requests_total", map[string]string{"method": "GET", "route": "/api/v1/users/:user_id", "status": "404"}))
+	assert.Equal(t, 3.0, metricValue(t, families, "ads_http_request_duration_seconds", route))
+	// Неизвестные пути не плодят отдельных рядов
+	assert.Equal(t, 1.0, metricValue(t, families, "ads_http_requests_total", map[string]string{"route": "unmatched", "status": "404"}))
+
+	// Удалённое объявление не учитывается, пустые состояния отдаются нулями
+	assert.Equal(t, 1.0, metricValue(t, families, "ads_ad_count", map[string]string{"status": "draft"}))
+	assert.Equal(t, 0.0, metricValue(t, families, "ads_ad_count", map[string]string{"status": "published"}))
+	assert.Equal(t, 2.0, metricValue(t, families, "ads_user_count", nil))
+}
+
+func TestGRPCMetrics(t *testing.T) {
+	a := newTestApp()
+	m := metrics.New()
+	m.RegisterApp(a)
+	client, ctx := dialTestServer(t, newGRPCServer(a, zap.NewNop(), m))
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "user", Email: "user@mail.com", UserId: 123, Password: testPassword})
+	require.NoError(t, err)
+	_, err = client.CreateAd(asUser(t, client, ctx, 123), &grpcPort.CreateAdRequest{Title: "hello", Text: "world"})
+	require.NoError(t, err)
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	families := scrape(t, m)
+	method := grpcPort.AdService_CreateAd_FullMethodName
+	assert.Equal(t, 1.0, metricValue(t, families, "ads_grpc_requests_total", map[string]string{"method": method, "code": codes.OK.String()}))
+	assert.Equal(t, 1.0, metricValue(t, families, "ads_grpc_requests_total", map[string]string{"method": method, "code": codes.Unauthenticated.String()}))
+	assert.Equal(t, 2.0, metricValue(t, families, "ads_grpc_request_duration_seconds", map[string]string{"method": method}))
+	assert.Equal(t, 1.0, metricValue(t, families, "ads_ad_count", map[string]string{"status": string(ads.StatusDraft)}))
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestModerationWorkflow(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	_, _ = client.createUser(testModeratorID, "moderator", "moderator@mail.com")
+	ad, err := client.createAd(123, "hello", "world")
+	assert.NoError(t, err)
+
+	// Автор не может опубликовать объявление сам
+	_, err = client.changeAdStatus(123, ad.Data.ID, "published", "")
+	assert.ErrorIs(t, err, ErrConflict)
+
+	ad, err = client.changeAdStatus(123, ad.Data.ID, "pending", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", ad.Data.Status)
+
+	_, err = client.changeAdStatus(123, ad.Data.ID, "published", "")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	queue, err := client.listAdsQuery(url.Values{"status": {"pending"}})
+	assert.NoError(t, err)
+	assert.Len(t, queue.Data, 1)
+	assert.Equal(t, ad.Data.ID, queue.Data[0].ID)
+
+	_, err = client.changeAdStatus(testModeratorID, ad.Data.ID, "rejected", "")
+	assert.ErrorIs(t, err, ErrUnprocessable)
+
+	ad, err = client.changeAdStatus(testModeratorID, ad.Data.ID, "rejected", "spam")
+	assert.NoError(t, err)
+	assert.Equal(t, "rejected", ad.Data.Status)
+	assert.Equal(t, "spam", ad.Data.RejectReason)
+	assert.False(t, ad.Data.Published)
+
+	ad, err = client.changeAdStatus(123, ad.Data.ID, "pending", "")
+	assert.NoError(t, err)
+	assert.Empty(t, ad.Data.RejectReason)
+
+	ad, err = client.changeAdStatus(testModeratorID, ad.Data.ID, "published", "")
+	assert.NoError(t, err)
+	assert.True(t, ad.Data.Published)
+
+	list, err := client.listAds()
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+
+	ad, err = client.changeAdStatus(123, ad.Data.ID, "archived", "")
+	assert.NoError(t, err)
+	assert.False(t, ad.Data.Published)
+
+	_, err = client.changeAdStatus(123, ad.Data.ID, "unknown", "")
+	assert.ErrorIs(t, err, ErrUnprocessable)
+}
+
+func TestGRPCModeration(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "moderator", Email: "moderator@mail.com", UserId: testModeratorID, Password: testPassword})
+	userCtx, modCtx := asUser(t, client, ctx, 123), asUser(t, client, ctx, testModeratorID)
+
+	ad, err := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"})
+	assert.NoError(t, err)
+
+	_, err = client.ChangeAdStatus(userCtx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, Status: grpcPort.AdStatus_AD_STATUS_PUBLISHED})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = client.ChangeAdStatus(userCtx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, Status: grpcPort.AdStatus_AD_STATUS_PENDING})
+	assert.NoError(t, err)
+
+	queue, err := client.ListAds(ctx, &grpcPort.FilterRequest{Status: grpcPort.AdStatus_AD_STATUS_PENDING})
+	assert.NoError(t, err)
+	assert.Len(t, queue.List, 1)
+
+	rejected, err := client.ChangeAdStatus(modCtx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, Status: grpcPort.AdStatus_AD_STATUS_REJECTED, Reason: "spam"})
+	assert.NoError(t, err)
+	assert.Equal(t, grpcPort.AdStatus_AD_STATUS_REJECTED, rejected.Status)
+	assert.Equal(t, "spam", rejected.RejectReason)
+
+	_, err = client.ChangeAdStatus(userCtx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestEditPublishedAdNeedsReview(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	ad, err := client.createAd(123, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.publishAd(123, ad.Data.ID)
+	assert.NoError(t, err)
+
+	// Одобренное объявление нельзя незаметно подменить: после правки оно снова ждёт проверки
+	ad, err = client.updateAd(123, ad.Data.ID, "hello", "buy pills")
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", ad.Data.Status)
+	assert.False(t, ad.Data.Published)
+
+	list, err := client.listAds()
+	assert.NoError(t, err)
+	assert.Empty(t, list.Data)
+
+	_, err = client.changeAdStatus(testModeratorID, ad.Data.ID, "published", "")
+	assert.NoError(t, err)
+	list, err = client.listAds()
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+}
+
+func TestGRPCEditPublishedAdNeedsReview(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	userCtx := asUser(t, client, ctx, 123)
+	ad, err := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"})
+	assert.NoError(t, err)
+	grpcPublishAd(t, client, ctx, userCtx, ad.Id)
+
+	ad, err = client.UpdateAd(userCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "hello", Text: "buy pills"})
+	assert.NoError(t, err)
+	assert.Equal(t, grpcPort.AdStatus_AD_STATUS_PENDING, ad.Status)
+
+	list, err := client.ListAds(ctx, &grpcPort.FilterRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, list.List)
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestAdRevisions(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	_, _ = client.createUser(124, "user1", "somemail1@mail.com")
+	ad, err := client.createAd(123, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.updateAd(123, ad.Data.ID, "привет", "мир")
+	assert.NoError(t, err)
+	_, err = client.updateAd(123, ad.Data.ID, "hi", "there")
+	assert.NoError(t, err)
+
+	revs, err := client.listAdRevisions(ad.Data.ID)
+	assert.NoError(t, err)
+	assert.Len(t, revs.Data, 3)
+	assert.Equal(t, revisionData{AdID: ad.Data.ID, Number: 1, Title: "hello", Text: "world", EditorID: 123}, revs.Data[0])
+	assert.Equal(t, "привет", revs.Data[1].Title)
+	assert.Equal(t, int64(3), revs.Data[2].Number)
+
+	_, err = client.restoreAdRevision(124, ad.Data.ID, 1)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.restoreAdRevision(123, ad.Data.ID, 4)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	restored, err := client.restoreAdRevision(123, ad.Data.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", restored.Data.Title)
+	assert.Equal(t, "world", restored.Data.Text)
+
+	// Восстановление не переписывает историю, а добавляет в неё версию
+	revs, err = client.listAdRevisions(ad.Data.ID)
+	assert.NoError(t, err)
+	assert.Len(t, revs.Data, 4)
+	assert.Equal(t, "hello", revs.Data[3].Title)
+
+	_, err = client.listAdRevisions(ad.Data.ID + 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGRPCAdRevisions(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	userCtx := asUser(t, client, ctx, 123)
+	ad, err := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"})
+	assert.NoError(t, err)
+	_, err = client.UpdateAd(userCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "title1", Text: "text1"})
+	assert.NoError(t, err)
+
+	revs, err := client.ListAdRevisions(ctx, &grpcPort.ListAdRevisionsRequest{AdId: ad.Id})
+	assert.NoError(t, err)
+	assert.Len(t, revs.Revisions, 2)
+	assert.Equal(t, "title1", revs.Revisions[1].Title)
+	assert.Equal(t, int64(123), revs.Revisions[1].EditorId)
+
+	_, err = client.RestoreAdRevision(ctx, &grpcPort.RestoreAdRevisionRequest{AdId: ad.Id, Number: 1})
+	assert.ErrorIs(t, err, ErrorUnauthenticated)
+
+	restored, err := client.RestoreAdRevision(userCtx, &grpcPort.RestoreAdRevisionRequest{AdId: ad.Id, Number: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", restored.Title)
+	assert.Equal(t, "world", restored.Text)
+}
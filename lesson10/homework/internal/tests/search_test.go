@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestSearchAds(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+
+	inText, _ := client.createAd(123, "For sale", "Red bike, almost new")
+	inTitle, _ := client.createAd(123, "Red bike for sale", "Call me")
+	other, _ := client.createAd(123, "Blue car", "Red and fast")
+	hidden, _ := client.createAd(123, "Red bike", "Not published yet")
+	for _, ad := range []adResponse{inText, inTitle, other} {
+		_, _ = client.publishAd(123, ad.Data.ID)
+	}
+
+	res, err := client.searchAds("BIKE red")
+	assert.NoError(t, err)
+	assert.Len(t, res.Data, 2)
+	assert.Equal(t, inTitle.Data.ID, res.Data[0].ID)
+	assert.Equal(t, inText.Data.ID, res.Data[1].ID)
+	assert.Greater(t, res.Data[0].Score, res.Data[1].Score)
+	for _, r := range res.Data {
+		assert.NotEqual(t, hidden.Data.ID, r.ID)
+	}
+
+	_, err = client.searchAds("  ,. ")
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestSearchAdsFollowsUpdates(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+
+	ad, _ := client.createAd(123, "Продаю велосипед", "Почти новый")
+	_, _ = client.publishAd(123, ad.Data.ID)
+
+	res, err := client.searchAds("велосипед")
+	assert.NoError(t, err)
+	assert.Len(t, res.Data, 1)
+
+	_, err = client.updateAd(123, ad.Data.ID, "Продаю самокат", "Почти новый")
+	assert.NoError(t, err)
+	// Изменённое объявление снова проходит проверку
+	_, err = client.changeAdStatus(testModeratorID, ad.Data.ID, "published", "")
+	assert.NoError(t, err)
+
+	res, err = client.searchAds("велосипед")
+	assert.NoError(t, err)
+	assert.Len(t, res.Data, 0)
+
+	res, err = client.searchAds("САМОКАТ")
+	assert.NoError(t, err)
+	assert.Len(t, res.Data, 1)
+
+	_, err = client.deleteAd(123, ad.Data.ID)
+	assert.NoError(t, err)
+
+	res, err = client.searchAds("самокат")
+	assert.NoError(t, err)
+	assert.Len(t, res.Data, 0)
+}
+
+func TestGRPCSearchAds(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	userCtx := asUser(t, client, ctx, 123)
+	ad, err := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "Red bike for sale", Text: "world"})
+	assert.NoError(t, err)
+	grpcPublishAd(t, client, ctx, userCtx, ad.Id)
+
+	res, err := client.SearchAds(ctx, &grpcPort.SearchAdsRequest{Query: "bike"})
+	assert.NoError(t, err)
+	assert.Len(t, res.Results, 1)
+	assert.Equal(t, ad.Id, res.Results[0].Ad.Id)
+	assert.Greater(t, res.Results[0].Score, float64(0))
+
+	_, err = client.SearchAds(ctx, &grpcPort.SearchAdsRequest{Query: ""})
+	assert.ErrorIs(t, err, ErrorBadRequest)
+}
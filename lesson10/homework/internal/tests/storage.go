@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/blobfs"
+	"homework10/internal/adapters/chatrepo"
+	"homework10/internal/adapters/favrepo"
+	"homework10/internal/adapters/pgrepo"
+	"homework10/internal/adapters/userrepo"
+	"homework10/internal/app"
+)
+
+// Если задана TEST_POSTGRES_DSN, весь набор тестов гоняется поверх PostgreSQL,
+// иначе - поверх in-memory репозиториев.
+const testPostgresDSNEnv = "TEST_POSTGRES_DSN"
+
+const migrationsDir = "../../migrations"
+
+// Пользователь с этим ID в тестовом приложении - модератор
+const testModeratorID = 1000000
+
+// Пользователь с этим ID в тестовом приложении - администратор
+const testAdminID = 1000001
+
+// Изображения всех тестов складываются в один временный каталог, TestMain удаляет его
+var testBlobDir = filepath.Join(os.TempDir(), "homework10-tests-"+strconv.Itoa(os.Getpid()))
+
+var (
+	pgOnce sync.Once
+	pgPool *pgxpool.Pool
+	pgErr  error
+)
+
+// newTestApp работает в режиме импорта: тесты сами задают ID пользователей.
+func newTestApp() app.App {
+	return newTestAppWith(app.WithUserImport())
+}
+
+func newTestAppWith(extra ...app.Option) app.App {
+	blobs, err := blobfs.New(testBlobDir)
+	if err != nil {
+		panic(err)
+	}
+	opts := []app.Option{app.WithModerators(testModeratorID), app.WithAdmins(testAdminID), app.WithBlobStore(blobs)}
+	opts = append(opts, extra...)
+
+	dsn := os.Getenv(testPostgresDSNEnv)
+	if dsn == "" {
+		return app.NewApp(adrepo.New(), userrepo.New(), favrepo.New(), chatrepo.New(), opts...)
+	}
+
+	ctx := context.Background()
+	pgOnce.Do(func() {
+		pgPool, pgErr = pgrepo.NewPool(ctx, dsn, zap.NewNop())
+		if pgErr == nil {
+			pgErr = migrate(ctx, pgPool)
+		}
+	})
+	if pgErr != nil {
+		panic(pgErr)
+	}
+
+	if _, err := pgPool.Exec(ctx, `TRUNCATE ads, ad_revisions, users, favorites, threads, messages RESTART IDENTITY`); err != nil {
+		panic(err)
+	}
+
+	return app.NewApp(pgrepo.New(pgPool), pgrepo.NewUsers(pgPool), pgrepo.NewFavorites(pgPool), pgrepo.NewChats(pgPool), opts...)
+}
+
+// migrate пересоздаёт схему и применяет все *.up.sql миграции по порядку версий.
+func migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	if _, err := pool.Exec(ctx, `DROP SCHEMA public CASCADE; CREATE SCHEMA public`); err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.up.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		query, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if _, err := pool.Exec(ctx, string(query)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,9 +3,6 @@ package tests
 import (
 	"context"
 	"github.com/stretchr/testify/assert"
-	"homework10/internal/adapters/adfilters"
-	"homework10/internal/adapters/adrepo"
-	"homework10/internal/adapters/userrepo"
 	"homework10/internal/ads"
 	"homework10/internal/app"
 	"testing"
@@ -35,11 +32,12 @@ func TestApp_InsertAdd(t *testing.T) {
 
 	for _, test := range appTests {
 		t.Run(test.name, func(t *testing.T) {
-			a := app.NewApp(adrepo.New(), userrepo.New(), adfilters.New())
+			a := newTestApp()
 			_, err := a.CreateUser(context.Background(), "nickname",
-				"somemail@mail.ru", AuthorID)
+				"somemail@mail.ru", "password", AuthorID)
 			assert.NoError(t, err)
-			ad, _ := a.CreateAd(context.Background(), test.title, test.text, test.userID)
+			ctx := app.WithUser(context.Background(), test.userID)
+			ad, _ := a.CreateAd(ctx, test.title, test.text, ads.Attributes{})
 			assert.Equal(t, ad.Title, test.expected.Title)
 			assert.Equal(t, ad.Text, test.expected.Text)
 			assert.Equal(t, ad.AuthorID, test.expected.AuthorID)
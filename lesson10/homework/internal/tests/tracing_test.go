@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+var (
+	spansOnce sync.Once
+	spans     *tracetest.InMemoryExporter
+)
+
+// recordSpans подключает глобальный TracerProvider к in-memory экспортёру. Провайдер
+// общий для всех тестов, поэтому span своего запроса тест ищет по ID трассировки.
+func recordSpans() *tracetest.InMemoryExporter {
+	spansOnce.Do(func() {
+		spans = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)))
+	})
+	return spans
+}
+
+// traceSpans возвращает завершённые span трассировки traceID по именам.
+func traceSpans(exporter *tracetest.InMemoryExporter, traceID string) map[string]tracetest.SpanStub {
+	byName := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		if span.SpanContext.TraceID().String() == traceID {
+			byName[span.Name] = span
+		}
+	}
+	return byName
+}
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestHTTPTracing(t *testing.T) {
+	exporter := recordSpans()
+	client := getTestClient()
+	_, err := client.createUser(123, "user", "user@mail.com")
+	require.NoError(t, err)
+	_, err = client.createAd(123, "hello", "world")
+	require.NoError(t, err)
+
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	req, err := http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/ads", nil)
+	require.NoError(t, err)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	var ads adsResponse
+	require.NoError(t, client.getResponse(req, &ads))
+
+	byName := traceSpans(exporter, traceID)
+	server, ok := byName["GET /api/v1/ads"]
+	require.True(t, ok, "no server span in %v", byName)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.True(t, server.Parent.IsRemote())
+	assert.Equal(t, parentID, server.Parent.SpanID().String())
+	assert.Equal(t, "/api/v1/ads", spanAttribute(server, semconv.HTTPRouteKey).AsString())
+	assert.Equal(t, int64(http.StatusOK), spanAttribute(server, semconv.HTTPStatusCodeKey).AsInt64())
+
+	// Вызов приложения вложен в span запроса, обращение к хранилищу - в вызов приложения
+	appSpan, ok := byName["App.GetAllAdsByFilter"]
+	require.True(t, ok)
+	assert.Equal(t, server.SpanContext.SpanID(), appSpan.Parent.SpanID())
+	repoSpan, ok := byName["Repository.GetAdsByFilter"]
+	require.True(t, ok)
+	assert.Equal(t, appSpan.SpanContext.SpanID(), repoSpan.Parent.SpanID())
+
+	// Ошибка хранилища отмечается в его span, а ответ 404 не считается сбоем сервера
+	const missingTraceID = "5bf92f3577b34da6a3ce929d0e0e4736"
+	req, err = http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/users/999", nil)
+	require.NoError(t, err)
+	req.Header.Set("traceparent", "00-"+missingTraceID+"-"+parentID+"-01")
+	var user userResponse
+	assert.ErrorIs(t, client.getResponse(req, &user), ErrNotFound)
+
+	byName = traceSpans(exporter, missingTraceID)
+	require.Contains(t, byName, "GET /api/v1/users/:user_id")
+	assert.Equal(t, codes.Unset, byName["GET /api/v1/users/:user_id"].Status.Code)
+	require.Contains(t, byName, "Users.Get")
+	assert.Equal(t, codes.Error, byName["Users.Get"].Status.Code)
+}
+
+func TestGRPCTracing(t *testing.T) {
+	exporter := recordSpans()
+	client, ctx := GetTestClient(t)
+
+	const traceID, parentID = "6bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	traceCtx := metadata.AppendToOutgoingContext(ctx, "traceparent", "00-"+traceID+"-"+parentID+"-01")
+	_, err := client.ListAds(traceCtx, &grpcPort.FilterRequest{})
+	require.NoError(t, err)
+
+	byName := traceSpans(exporter, traceID)
+	server, ok := byName[grpcPort.AdService_ListAds_FullMethodName]
+	require.True(t, ok, "no server span in %v", byName)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, parentID, server.Parent.SpanID().String())
+	assert.Equal(t, "ListAds", spanAttribute(server, semconv.RPCMethodKey).AsString())
+
+	appSpan, ok := byName["App.GetAllAdsByFilter"]
+	require.True(t, ok)
+	assert.Equal(t, server.SpanContext.SpanID(), appSpan.Parent.SpanID())
+	repoSpan, ok := byName["Repository.GetAdsByFilter"]
+	require.True(t, ok)
+	assert.Equal(t, appSpan.SpanContext.SpanID(), repoSpan.Parent.SpanID())
+}
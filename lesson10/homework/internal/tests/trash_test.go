@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestSoftDeleteAd(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	_, _ = client.createUser(testAdminID, "admin", "admin@mail.com")
+	ad, err := client.createAd(123, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.publishAd(123, ad.Data.ID)
+	assert.NoError(t, err)
+
+	deleted, err := client.deleteAd(123, ad.Data.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, deleted.Data.DeletedAt)
+
+	list, err := client.listAds()
+	assert.NoError(t, err)
+	assert.Empty(t, list.Data)
+	_, err = client.listAdRevisions(ad.Data.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = client.deleteAd(123, ad.Data.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Удалённое видит и восстанавливает только администратор
+	_, err = client.listDeletedAds(123)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.restoreAd(123, ad.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	trash, err := client.listDeletedAds(testAdminID)
+	assert.NoError(t, err)
+	assert.Len(t, trash.Data, 1)
+	assert.Equal(t, ad.Data.ID, trash.Data[0].ID)
+
+	restored, err := client.restoreAd(testAdminID, ad.Data.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, restored.Data.DeletedAt)
+	assert.True(t, restored.Data.Published)
+
+	list, err = client.listAds()
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+	_, err = client.restoreAd(testAdminID, ad.Data.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDeleteUserCascade(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	_, _ = client.createUser(testAdminID, "admin", "admin@mail.com")
+	kept, err := client.createAd(123, "kept", "ad")
+	assert.NoError(t, err)
+	dropped, err := client.createAd(123, "dropped", "earlier")
+	assert.NoError(t, err)
+	_, err = client.deleteAd(123, dropped.Data.ID)
+	assert.NoError(t, err)
+
+	_, err = client.deleteUserByID(123)
+	assert.NoError(t, err)
+	_, err = client.createAd(123, "after", "delete")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, err = client.createUser(123, "user", "somemail@mail.com")
+	assert.ErrorIs(t, err, ErrConflict)
+
+	trash, err := client.listDeletedAds(testAdminID)
+	assert.NoError(t, err)
+	assert.Len(t, trash.Data, 2)
+
+	_, err = client.restoreAd(testAdminID, kept.Data.ID)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	_, err = client.restoreUser(testAdminID, 123)
+	assert.NoError(t, err)
+
+	// Возвращаются только объявления, удалённые вместе с пользователем
+	trash, err = client.listDeletedAds(testAdminID)
+	assert.NoError(t, err)
+	assert.Len(t, trash.Data, 1)
+	assert.Equal(t, dropped.Data.ID, trash.Data[0].ID)
+
+	_, err = client.updateAd(123, kept.Data.ID, "kept", "again")
+	assert.NoError(t, err)
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	a := newTestApp()
+	ctx := context.Background()
+	_, err := a.CreateUser(ctx, "nickname", "somemail@mail.ru", testPassword, 123)
+	assert.NoError(t, err)
+	_, err = a.CreateUser(ctx, "admin", "admin@mail.ru", testPassword, testAdminID)
+	assert.NoError(t, err)
+	userCtx, adminCtx := app.WithUser(ctx, 123), app.WithUser(ctx, testAdminID)
+
+	ad, err := a.CreateAd(userCtx, "title", "text", ads.Attributes{})
+	assert.NoError(t, err)
+	_, err = a.CreateAd(userCtx, "other", "text", ads.Attributes{})
+	assert.NoError(t, err)
+	_, err = a.DeleteAd(userCtx, ad.ID, app.AnyVersion)
+	assert.NoError(t, err)
+
+	// Срок хранения ещё не истёк
+	purged, err := a.PurgeDeleted(ctx, time.Now().UTC().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, app.Purged{}, purged)
+
+	_, err = a.DeleteUser(userCtx, 123)
+	assert.NoError(t, err)
+	purged, err = a.PurgeDeleted(ctx, time.Now().UTC().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, app.Purged{Ads: 2, Users: 1}, purged)
+
+	_, err = a.RestoreUser(adminCtx, 123)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+	_, err = a.RestoreAd(adminCtx, ad.ID)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+
+	// После очистки ID свободен
+	_, err = a.CreateUser(ctx, "nickname", "somemail@mail.ru", testPassword, 123)
+	assert.NoError(t, err)
+}
+
+func TestRunPurge(t *testing.T) {
+	a := newTestApp()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := a.CreateUser(ctx, "nickname", "somemail@mail.ru", testPassword, 123)
+	assert.NoError(t, err)
+	_, err = a.DeleteUser(app.WithUser(ctx, 123), 123)
+	assert.NoError(t, err)
+
+	reports := make(chan app.Purged, 1)
+	go app.RunPurge(ctx, a, 10*time.Millisecond, 0, func(p app.Purged, err error) {
+		assert.NoError(t, err)
+		if p.Users > 0 {
+			reports <- p
+		}
+	})
+
+	select {
+	case p := <-reports:
+		assert.Equal(t, int64(1), p.Users)
+	case <-time.After(time.Second):
+		t.Fatal("deleted user was not purged")
+	}
+}
+
+func TestGRPCRestore(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", UserId: 123, Password: testPassword})
+	_, _ = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "admin", Email: "admin@mail.com", UserId: testAdminID, Password: testPassword})
+	userCtx, adminCtx := asUser(t, client, ctx, 123), asUser(t, client, ctx, testAdminID)
+
+	ad, err := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world"})
+	assert.NoError(t, err)
+	deleted, err := client.DeleteUserByID(userCtx, &grpcPort.DeleteUserRequest{Id: 123})
+	assert.NoError(t, err)
+	assert.NotNil(t, deleted.DeletedAt)
+
+	_, err = client.GetAd(ctx, &grpcPort.GetAdRequest{Id: ad.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.RestoreUser(userCtx, &grpcPort.RestoreUserRequest{UserId: 123})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	// Токен удалённого пользователя не даёт удалять объявления
+	_, err = client.DeleteAd(userCtx, &grpcPort.DeleteAdRequest{AdId: ad.Id})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.RestoreAd(adminCtx, &grpcPort.RestoreAdRequest{AdId: ad.Id})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	trash, err := client.ListDeletedAds(adminCtx, &grpcPort.ListDeletedAdsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, trash.List, 1)
+	assert.NotNil(t, trash.List[0].DeletedAt)
+
+	restored, err := client.RestoreUser(adminCtx, &grpcPort.RestoreUserRequest{UserId: 123})
+	assert.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+
+	res, err := client.GetAd(ctx, &grpcPort.GetAdRequest{Id: ad.Id})
+	assert.NoError(t, err)
+	assert.Nil(t, res.DeletedAt)
+}
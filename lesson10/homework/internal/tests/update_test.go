@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+func TestUpdateAdVersionConflict(t *testing.T) {
+	a := newTestApp()
+	_, err := a.CreateUser(context.Background(), "nickname", "somemail@mail.ru", testPassword, 123)
+	assert.NoError(t, err)
+	ctx := app.WithUser(context.Background(), 123)
+
+	ad, err := a.CreateAd(ctx, "title", "text", ads.Attributes{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), ad.Version)
+
+	// Два клиента получили первую версию, первый успел сохранить правку
+	updated, err := a.UpdateAd(ctx, ad.ID, "first", "client", nil, ad.Version)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+
+	_, err = a.UpdateAd(ctx, ad.ID, "second", "client", nil, ad.Version)
+	assert.ErrorIs(t, err, app.ErrVersionConflict)
+
+	current, err := a.GetAd(ctx, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", current.Title)
+	assert.Equal(t, int64(2), current.Version)
+
+	_, err = a.UpdateAd(ctx, ad.ID, "any", "version", nil, app.AnyVersion)
+	assert.NoError(t, err)
+}
+
+func TestRepositoryUpdate(t *testing.T) {
+	repo := adrepo.New()
+	ctx := context.Background()
+	ad, err := repo.Add(ctx, "title", "text", ads.Attributes{}, 123)
+	assert.NoError(t, err)
+
+	errStop := errors.New("stop")
+	_, err = repo.Update(ctx, ad.ID, func(ad *ads.Ad) error {
+		ad.Title = "changed"
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+
+	updated, err := repo.Update(ctx, ad.ID, func(ad *ads.Ad) error {
+		ad.AuthorID = 124
+		ad.Status = ads.StatusPublished
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "title", updated.Title)
+	assert.Equal(t, int64(123), updated.AuthorID)
+	assert.True(t, updated.Published)
+	assert.Equal(t, ad.Version+1, updated.Version)
+
+	// Версия содержимого записывается вместе с изменением заголовка или текста и только с ним
+	revs, err := repo.Revisions(ctx, ad.ID)
+	assert.NoError(t, err)
+	assert.Len(t, revs, 1)
+	updated, err = repo.Update(ctx, ad.ID, func(ad *ads.Ad) error {
+		ad.Text = "changed"
+		return nil
+	})
+	assert.NoError(t, err)
+	revs, err = repo.Revisions(ctx, ad.ID)
+	assert.NoError(t, err)
+	assert.Len(t, revs, 2)
+	assert.Equal(t, ads.Revision{AdID: ad.ID, Number: 2, Title: "title", Text: "changed", EditorID: 123, CreationDate: updated.UpdateDate}, revs[1])
+
+	_, err = repo.Update(ctx, ad.ID+1, func(ad *ads.Ad) error { return nil })
+	assert.ErrorIs(t, err, app.ErrNotFound)
+}
+
+func TestRepositoryUpdateAtomic(t *testing.T) {
+	repo := adrepo.New()
+	ctx := context.Background()
+	ad, err := repo.Add(ctx, "a", "a", ads.Attributes{}, 123)
+	assert.NoError(t, err)
+
+	const writers, updates = 4, 100
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		value := string(rune('a' + i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < updates; j++ {
+				_, err := repo.Update(ctx, ad.ID, func(ad *ads.Ad) error {
+					ad.Title = value
+					ad.Text = value
+					return nil
+				})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			res, err := repo.Find(ctx, ad.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, ad.Version+writers*updates, res.Version)
+			// Версии записываются в том же порядке, в каком применялись правки
+			revs, err := repo.Revisions(ctx, ad.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, res.Title, revs[len(revs)-1].Title)
+			return
+		default:
+			// Заголовок и текст меняются вместе, читатель не видит объявление изменённым наполовину
+			res, err := repo.Find(ctx, ad.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, res.Title, res.Text)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/chatrepo"
+	"homework10/internal/adapters/favrepo"
+	"homework10/internal/adapters/userrepo"
+	"homework10/internal/app"
+	"homework10/internal/metrics"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/user"
+)
+
+func TestCreateUserAllocatesID(t *testing.T) {
+	client := newTestClient(newTestAppWith(), zap.NewNop(), metrics.New())
+
+	first, err := client.createUser(0, "first", "first@mail.com")
+	require.NoError(t, err)
+	second, err := client.createUser(0, "second", "second@mail.com")
+	require.NoError(t, err)
+	assert.NotZero(t, first.Data.ID)
+	assert.NotEqual(t, first.Data.ID, second.Data.ID)
+
+	// Выданный ID подходит для входа
+	ad, err := client.createAd(second.Data.ID, "hello", "world")
+	require.NoError(t, err)
+	assert.Equal(t, second.Data.ID, ad.Data.AuthorID)
+
+	// Без режима импорта свой ID задаёт только администратор
+	_, err = client.createUser(500, "third", "third@mail.com")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.createUserAs(first.Data.ID, 500, "third", "third@mail.com")
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestCreateUserByAdmin(t *testing.T) {
+	ctx := context.Background()
+	users := userrepo.New()
+	// Администратор заведён в хранилище заранее, как при переносе пользователей
+	_, err := users.Create(ctx, "admin", "admin@mail.com", "", testAdminID)
+	require.NoError(t, err)
+	a := app.NewApp(adrepo.New(), users, favrepo.New(), chatrepo.New(), app.WithAdmins(testAdminID))
+
+	_, err = a.CreateUser(ctx, "imported", "imported@mail.com", testPassword, 2000000)
+	assert.ErrorIs(t, err, app.ErrAccessDenied)
+	u, err := a.CreateUser(app.WithUser(ctx, testAdminID), "imported", "imported@mail.com", testPassword, 2000000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2000000), u.ID)
+
+	// Новые ID выдаются после заданных вручную
+	u, err = a.CreateUser(ctx, "fresh", "fresh@mail.com", testPassword, 0)
+	require.NoError(t, err)
+	assert.Greater(t, u.ID, int64(2000000))
+
+	_, err = a.CreateUser(app.WithUser(ctx, testAdminID), "other", "other@mail.com", testPassword, -1)
+	assert.ErrorIs(t, err, app.ErrValidation)
+}
+
+func TestCreateUserUnique(t *testing.T) {
+	client := getTestClient()
+	_, err := client.createUser(123, "user", "somemail@mail.com")
+	require.NoError(t, err)
+	_, err = client.createUser(124, "other", "other@mail.com")
+	require.NoError(t, err)
+
+	_, err = client.createUser(125, "USER", "third@mail.com")
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = client.createUser(125, "third", "SomeMail@Mail.com")
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = client.changeUserInfo(124, "User", "other@mail.com")
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = client.changeUserInfo(124, "other", "SOMEMAIL@mail.com")
+	assert.ErrorIs(t, err, ErrConflict)
+
+	// Смена регистра своих никнейма и email конфликтом не считается
+	res, err := client.changeUserInfo(124, "Other", "Other@mail.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Other", res.Data.Nickname)
+
+	// Удалённый пользователь занимает никнейм и email до очистки
+	_, err = client.deleteUserByID(123)
+	require.NoError(t, err)
+	_, err = client.createUser(125, "user", "third@mail.com")
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+func TestCreateUserValidation(t *testing.T) {
+	client := getTestClient()
+	_, err := client.createUser(123, "user", "somemail@mail.com")
+	require.NoError(t, err)
+
+	for _, c := range []struct{ nickname, email string }{
+		{"ab", "short@mail.com"},
+		{strings.Repeat("a", 31), "long@mail.com"},
+		{"1user", "digit@mail.com"},
+		{"user name", "space@mail.com"},
+		{"user@name", "at@mail.com"},
+		{"valid", "not an email"},
+		{"valid", "user@localhost"},
+		{"valid", "Name <name@mail.com>"},
+		{"valid", "@mail.com"},
+	} {
+		_, err := client.createUser(124, c.nickname, c.email)
+		assert.ErrorIs(t, err, ErrUnprocessable, c)
+	}
+	_, err = client.changeUserInfo(123, "user", "broken")
+	assert.ErrorIs(t, err, ErrUnprocessable)
+
+	for _, nickname := range []string{"Алиса", "user_1", "user.name", "user-name"} {
+		_, err = client.createUser(0, nickname, nickname+"@mail.com")
+		assert.NoError(t, err, nickname)
+	}
+
+	// Ошибки всех полей возвращаются вместе
+	a := newTestAppWith()
+	_, err = a.CreateUser(context.Background(), "1", "mail", "short", 0)
+	var validationErr *app.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []app.FieldError{
+		{Field: "nickname", Reason: "length must be between 3 and 30"},
+		{Field: "email", Reason: "must be a valid email address"},
+		{Field: "password", Reason: "length must be between 8 and 72"},
+	}, validationErr.Fields)
+}
+
+func TestGRPCCreateUserAllocatesID(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	u, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", Password: testPassword})
+	require.NoError(t, err)
+	assert.NotZero(t, u.UserId)
+	userCtx := asUser(t, client, ctx, u.UserId)
+	ad, err := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text"})
+	require.NoError(t, err)
+	assert.Equal(t, u.UserId, ad.AuthorId)
+
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "NAME", Email: "other@mail.com", Password: testPassword})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "other", Email: "other", Password: testPassword})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUserEmailVisibility(t *testing.T) {
+	client := getTestClient()
+	_, err := client.createUser(123, "user", "somemail@mail.com")
+	require.NoError(t, err)
+	_, err = client.createUser(124, "other", "other@mail.com")
+	require.NoError(t, err)
+	_, err = client.createUser(testAdminID, "admin", "admin@mail.com")
+	require.NoError(t, err)
+
+	// Анонимный клиент и другой пользователь видят публичный профиль без email
+	for _, callerID := range []int64{0, 124} {
+		res, err := client.getUserAs(callerID, 123)
+		require.NoError(t, err)
+		assert.Equal(t, "user", res.Data.Nickname)
+		assert.Empty(t, res.Data.Email, callerID)
+	}
+
+	for _, callerID := range []int64{123, testAdminID} {
+		res, err := client.getUserAs(callerID, 123)
+		require.NoError(t, err)
+		assert.Equal(t, "somemail@mail.com", res.Data.Email, callerID)
+	}
+}
+
+func TestUserProfile(t *testing.T) {
+	client := getTestClient()
+	created, err := client.createUser(123, "user", "somemail@mail.com")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), created.Data.RegistrationDate, time.Minute)
+	assert.Equal(t, ratingData{}, created.Data.Rating)
+
+	_, err = client.changeUserInfoWith(123, map[string]any{
+		"nickname":     "user",
+		"email":        "somemail@mail.com",
+		"display_name": "Иван Петров",
+		"avatar_url":   "https://example.com/avatar.png",
+	})
+	require.NoError(t, err)
+
+	res, err := client.getUser(123)
+	require.NoError(t, err)
+	assert.Equal(t, "user", res.Data.Nickname)
+	assert.Equal(t, "Иван Петров", res.Data.DisplayName)
+	assert.Equal(t, "https://example.com/avatar.png", res.Data.AvatarURL)
+	assert.Equal(t, created.Data.RegistrationDate, res.Data.RegistrationDate)
+
+	// PUT заменяет профиль целиком
+	res, err = client.changeUserInfo(123, "user", "somemail@mail.com")
+	require.NoError(t, err)
+	assert.Empty(t, res.Data.DisplayName)
+	assert.Empty(t, res.Data.AvatarURL)
+
+	for _, avatar := range []string{"avatar.png", "ftp://example.com/a.png", "https://"} {
+		_, err = client.changeUserInfoWith(123, map[string]any{"nickname": "user", "email": "somemail@mail.com", "avatar_url": avatar})
+		assert.ErrorIs(t, err, ErrUnprocessable, avatar)
+	}
+	_, err = client.changeUserInfoWith(123, map[string]any{"nickname": "user", "email": "somemail@mail.com", "display_name": strings.Repeat("a", 61)})
+	assert.ErrorIs(t, err, ErrUnprocessable)
+
+	_, err = client.getUser(124)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = client.deleteUserByID(123)
+	require.NoError(t, err)
+	_, err = client.getUser(123)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Имена полей в ошибке - как в API
+	a := newTestApp()
+	ctx := context.Background()
+	_, err = a.CreateUser(ctx, "user", "somemail@mail.com", testPassword, 123)
+	require.NoError(t, err)
+	_, err = a.ChangeUserInfo(app.WithUser(ctx, 123), 123, "user", "somemail@mail.com", &user.Profile{AvatarURL: "avatar"})
+	var validationErr *app.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []app.FieldError{{Field: "avatar_url", Reason: "must be an http or https URL"}}, validationErr.Fields)
+}
+
+func TestListUserAds(t *testing.T) {
+	client := getTestClient()
+	_, _ = client.createUser(123, "user", "somemail@mail.com")
+	_, _ = client.createUser(124, "other", "other@mail.com")
+
+	var published []int64
+	for i := 0; i < 3; i++ {
+		ad, err := client.createAd(123, "hello", "world")
+		require.NoError(t, err)
+		_, err = client.publishAd(123, ad.Data.ID)
+		require.NoError(t, err)
+		published = append(published, ad.Data.ID)
+	}
+	_, err := client.createAd(123, "draft", "text")
+	require.NoError(t, err)
+	other, err := client.createAd(124, "other", "text")
+	require.NoError(t, err)
+	_, err = client.publishAd(124, other.Data.ID)
+	require.NoError(t, err)
+
+	page, err := client.listUserAds(123, url.Values{"limit": {"2"}})
+	require.NoError(t, err)
+	require.Len(t, page.Data, 2)
+	assert.Equal(t, published[:2], []int64{page.Data[0].ID, page.Data[1].ID})
+	page, err = client.listUserAds(123, url.Values{"limit": {"2"}, "cursor": {page.NextCursor}})
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, published[2], page.Data[0].ID)
+
+	_, err = client.listUserAds(125, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = client.listUserAds(123, url.Values{"sort": {"unknown"}})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestGRPCUserProfile(t *testing.T) {
+	client, ctx := GetTestClient(t)
+
+	u, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "name", Email: "somemail@mail.com", Password: testPassword})
+	require.NoError(t, err)
+	userCtx := asUser(t, client, ctx, u.UserId)
+
+	res, err := client.ChangeUserInfo(userCtx, &grpcPort.ChangeUserInfoRequest{UserId: u.UserId, Nickname: "name", Email: "somemail@mail.com",
+		Profile: &grpcPort.Profile{DisplayName: "Name", AvatarUrl: "http://example.com/a.jpg"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Name", res.DisplayName)
+
+	// Без profile профиль сохраняется
+	res, err = client.ChangeUserInfo(userCtx, &grpcPort.ChangeUserInfoRequest{UserId: u.UserId, Nickname: "new_name", Email: "somemail@mail.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "new_name", res.Nickname)
+	assert.Equal(t, "http://example.com/a.jpg", res.AvatarUrl)
+
+	got, err := client.GetUser(ctx, &grpcPort.GetUserRequest{Id: u.UserId})
+	require.NoError(t, err)
+	assert.Equal(t, "Name", got.DisplayName)
+	assert.Equal(t, u.RegistrationDate.AsTime(), got.RegistrationDate.AsTime())
+	assert.Equal(t, int64(0), got.Rating.Votes)
+
+	ad, err := client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text"})
+	require.NoError(t, err)
+	list, err := client.ListUserAds(ctx, &grpcPort.ListUserAdsRequest{UserId: u.UserId})
+	require.NoError(t, err)
+	assert.Empty(t, list.List)
+	grpcPublishAd(t, client, ctx, userCtx, ad.Id)
+	list, err = client.ListUserAds(ctx, &grpcPort.ListUserAdsRequest{UserId: u.UserId})
+	require.NoError(t, err)
+	require.Len(t, list.List, 1)
+	assert.Equal(t, ad.Id, list.List[0].Id)
+
+	_, err = client.ListUserAds(ctx, &grpcPort.ListUserAdsRequest{UserId: u.UserId + 100})
+	assert.ErrorIs(t, err, ErrorNotFound)
+}
@@ -4,29 +4,80 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"homework10/internal/adapters/adfilters"
-	"homework10/internal/adapters/userrepo"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
 
-	"homework10/internal/adapters/adrepo"
 	"homework10/internal/app"
+	"homework10/internal/auth"
+	"homework10/internal/metrics"
 	"homework10/internal/ports/httpgin"
 )
 
+// Пароль, с которым createUser регистрирует пользователей
+const testPassword = "password"
+
 type adData struct {
-	ID        int64  `json:"id"`
-	Title     string `json:"title"`
-	Text      string `json:"text"`
-	AuthorID  int64  `json:"author_id"`
-	Published bool   `json:"published"`
+	ID           int64       `json:"id"`
+	Title        string      `json:"title"`
+	Text         string      `json:"text"`
+	AuthorID     int64       `json:"author_id"`
+	Published    bool        `json:"published"`
+	Status       string      `json:"status"`
+	RejectReason string      `json:"reject_reason"`
+	Version      int64       `json:"version"`
+	Images       []imageData `json:"images"`
+	Category     string      `json:"category"`
+	Tags         []string    `json:"tags"`
+	Price        *priceData  `json:"price"`
+	Location     string      `json:"location"`
+	// Задано только у удалённого объявления
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+type priceData struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type categoryData struct {
+	ID       string         `json:"id"`
+	Name     string         `json:"name"`
+	Children []categoryData `json:"children"`
+}
+
+type categoriesResponse struct {
+	Data []categoryData `json:"data"`
+}
+
+type imageData struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
 }
 
 type userData struct {
-	Nickname string `json:"nickname" binding:"required"`
-	Email    string `json:"email" binding:"required"`
-	ID       int64  `json:"user_id" binding:"required"`
+	Nickname         string     `json:"nickname" binding:"required"`
+	Email            string     `json:"email" binding:"required"`
+	ID               int64      `json:"user_id" binding:"required"`
+	DisplayName      string     `json:"display_name"`
+	AvatarURL        string     `json:"avatar_url"`
+	RegistrationDate time.Time  `json:"registration_date"`
+	Rating           ratingData `json:"rating"`
+}
+
+type ratingData struct {
+	Score float64 `json:"score"`
+	Votes int64   `json:"votes"`
 }
 
 type adResponse struct {
@@ -37,66 +88,176 @@ type userResponse struct {
 	Data userData `json:"data"`
 }
 
+type searchResultData struct {
+	adData
+	Score float64 `json:"score"`
+}
+
+type searchResponse struct {
+	Data []searchResultData `json:"data"`
+}
+
+type loginData struct {
+	UserID int64  `json:"user_id"`
+	Token  string `json:"token"`
+}
+
+type loginResponse struct {
+	Data loginData `json:"data"`
+}
+
+type revisionData struct {
+	AdID     int64  `json:"ad_id"`
+	Number   int64  `json:"number"`
+	Title    string `json:"title"`
+	Text     string `json:"text"`
+	EditorID int64  `json:"editor_id"`
+}
+
+type revisionsResponse struct {
+	Data []revisionData `json:"data"`
+}
+
 type adsResponse struct {
-	Data []adData `json:"data"`
+	Data       []adData `json:"data"`
+	NextCursor string   `json:"next_cursor"`
+}
+
+type messageData struct {
+	ID       int64      `json:"id"`
+	ThreadID int64      `json:"thread_id"`
+	SenderID int64      `json:"sender_id"`
+	Text     string     `json:"text"`
+	ReadAt   *time.Time `json:"read_at"`
+}
+
+type messageResponse struct {
+	Data messageData `json:"data"`
+}
+
+type messagesResponse struct {
+	Data []messageData `json:"data"`
+}
+
+type threadData struct {
+	ID          int64       `json:"id"`
+	AdID        int64       `json:"ad_id"`
+	BuyerID     int64       `json:"buyer_id"`
+	SellerID    int64       `json:"seller_id"`
+	LastMessage messageData `json:"last_message"`
+	Unread      int64       `json:"unread"`
+}
+
+type threadsResponse struct {
+	Data []threadData `json:"data"`
 }
 
 var (
-	ErrBadRequest = fmt.Errorf("bad request")
-	ErrForbidden  = fmt.Errorf("forbidden")
+	ErrBadRequest         = fmt.Errorf("bad request")
+	ErrForbidden          = fmt.Errorf("forbidden")
+	ErrUnauthorized       = fmt.Errorf("unauthorized")
+	ErrNotFound           = fmt.Errorf("not found")
+	ErrConflict           = fmt.Errorf("conflict")
+	ErrUnprocessable      = fmt.Errorf("unprocessable entity")
+	ErrPreconditionFailed = fmt.Errorf("precondition failed")
+	ErrTooLarge           = fmt.Errorf("request entity too large")
+	ErrUnsupportedMedia   = fmt.Errorf("unsupported media type")
 )
 
 type testClient struct {
 	client  *http.Client
 	baseURL string
+	// Токены пользователей, созданных через createUser
+	tokens map[int64]string
 }
 
 func getTestClient() *testClient {
-	server := httpgin.NewHTTPServer(":18080", app.NewApp(adrepo.New(), userrepo.New(), adfilters.New()))
+	return newTestClient(newTestApp(), zap.NewNop(), metrics.New())
+}
+
+func newTestClient(a app.App, logger *zap.Logger, m *metrics.Metrics) *testClient {
+	server := httpgin.NewHTTPServer(":18080", a, auth.NewTokens([]byte("test secret"), time.Hour), logger, m)
 	testServer := httptest.NewServer(server.Handler)
 
 	return &testClient{
 		client:  testServer.Client(),
 		baseURL: testServer.URL,
+		tokens:  map[int64]string{},
+	}
+}
+
+// authorize подписывает запрос токеном пользователя userID, если он известен клиенту.
+func (tc *testClient) authorize(req *http.Request, userID int64) {
+	if token, ok := tc.tokens[userID]; ok {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
 }
 
 func (tc *testClient) getResponse(req *http.Request, out any) error {
+	_, err := tc.getResponseHeader(req, out)
+	return err
+}
+
+// getResponseHeader, в отличие от getResponse, возвращает и заголовки успешного ответа.
+func (tc *testClient) getResponseHeader(req *http.Request, out any) (http.Header, error) {
 	resp, err := tc.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("unexpected error: %w", err)
+		return nil, fmt.Errorf("unexpected error: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusBadRequest {
-			return ErrBadRequest
+			return nil, ErrBadRequest
 		}
 		if resp.StatusCode == http.StatusForbidden {
-			return ErrForbidden
+			return nil, ErrForbidden
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, ErrUnauthorized
 		}
-		return fmt.Errorf("unexpected status code: %s", resp.Status)
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		if resp.StatusCode == http.StatusConflict {
+			return nil, ErrConflict
+		}
+		if resp.StatusCode == http.StatusUnprocessableEntity {
+			return nil, ErrUnprocessable
+		}
+		if resp.StatusCode == http.StatusPreconditionFailed {
+			return nil, ErrPreconditionFailed
+		}
+		if resp.StatusCode == http.StatusRequestEntityTooLarge {
+			return nil, ErrTooLarge
+		}
+		if resp.StatusCode == http.StatusUnsupportedMediaType {
+			return nil, ErrUnsupportedMedia
+		}
+		return nil, fmt.Errorf("unexpected status code: %s", resp.Status)
 	}
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("unable to read response: %w", err)
+		return nil, fmt.Errorf("unable to read response: %w", err)
 	}
 
 	err = json.Unmarshal(respBody, out)
 	if err != nil {
-		return fmt.Errorf("unable to unmarshal: %w", err)
+		return nil, fmt.Errorf("unable to unmarshal: %w", err)
 	}
 
-	return nil
+	return resp.Header, nil
 }
 
 func (tc *testClient) createAd(userID int64, title string, text string) (adResponse, error) {
-	body := map[string]any{
-		"user_id": userID,
-		"title":   title,
-		"text":    text,
-	}
+	return tc.createAdWith(userID, map[string]any{
+		"title": title,
+		"text":  text,
+	})
+}
 
+// createAdWith отправляет тело запроса как есть, например с атрибутами объявления.
+func (tc *testClient) createAdWith(userID int64, body map[string]any) (adResponse, error) {
 	data, err := json.Marshal(body)
 	if err != nil {
 		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
@@ -108,6 +269,7 @@ func (tc *testClient) createAd(userID int64, title string, text string) (adRespo
 	}
 
 	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
 
 	var response adResponse
 	err = tc.getResponse(req, &response)
@@ -118,10 +280,10 @@ func (tc *testClient) createAd(userID int64, title string, text string) (adRespo
 	return response, nil
 }
 
-func (tc *testClient) changeAdStatus(userID int64, adID int64, published bool) (adResponse, error) {
+func (tc *testClient) changeAdStatus(userID int64, adID int64, status string, reason string) (adResponse, error) {
 	body := map[string]any{
-		"user_id":   userID,
-		"published": published,
+		"status": status,
+		"reason": reason,
 	}
 
 	data, err := json.Marshal(body)
@@ -135,6 +297,7 @@ func (tc *testClient) changeAdStatus(userID int64, adID int64, published bool) (
 	}
 
 	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
 
 	var response adResponse
 	err = tc.getResponse(req, &response)
@@ -145,13 +308,29 @@ func (tc *testClient) changeAdStatus(userID int64, adID int64, published bool) (
 	return response, nil
 }
 
-func (tc *testClient) updateAd(userID int64, adID int64, title string, text string) (adResponse, error) {
-	body := map[string]any{
-		"user_id": userID,
-		"title":   title,
-		"text":    text,
+// publishAd отправляет объявление автора userID на проверку и одобряет его модератором.
+func (tc *testClient) publishAd(userID int64, adID int64) (adResponse, error) {
+	if _, ok := tc.tokens[testModeratorID]; !ok {
+		if _, err := tc.createUser(testModeratorID, "moderator", "moderator@mail.com"); err != nil {
+			return adResponse{}, err
+		}
+	}
+
+	_, err := tc.changeAdStatus(userID, adID, "pending", "")
+	if err != nil {
+		return adResponse{}, err
 	}
+	return tc.changeAdStatus(testModeratorID, adID, "published", "")
+}
 
+func (tc *testClient) updateAd(userID int64, adID int64, title string, text string) (adResponse, error) {
+	return tc.updateAdWith(userID, adID, map[string]any{
+		"title": title,
+		"text":  text,
+	})
+}
+
+func (tc *testClient) updateAdWith(userID int64, adID int64, body map[string]any) (adResponse, error) {
 	data, err := json.Marshal(body)
 	if err != nil {
 		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
@@ -163,6 +342,7 @@ func (tc *testClient) updateAd(userID int64, adID int64, title string, text stri
 	}
 
 	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
 
 	var response adResponse
 	err = tc.getResponse(req, &response)
@@ -173,6 +353,91 @@ func (tc *testClient) updateAd(userID int64, adID int64, title string, text stri
 	return response, nil
 }
 
+func (tc *testClient) listAdRevisions(adID int64) (revisionsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/revisions", adID), nil)
+	if err != nil {
+		return revisionsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response revisionsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return revisionsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) restoreAdRevision(userID int64, adID int64, number int64) (adResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/revisions/%d/restore", adID, number), nil)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) uploadImage(userID int64, adID int64, data []byte) (adResponse, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("image", "image")
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create form: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return adResponse{}, fmt.Errorf("unable to create form: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return adResponse{}, fmt.Errorf("unable to create form: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/images", adID), &body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", w.FormDataContentType())
+	tc.authorize(req, userID)
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+// getFile возвращает содержимое и тип ответа по пути path, например адресу изображения.
+func (tc *testClient) getFile(path string) ([]byte, string, error) {
+	resp, err := tc.client.Get(tc.baseURL + path)
+	if err != nil {
+		return nil, "", fmt.Errorf("unexpected error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status code: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read response: %w", err)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
 func (tc *testClient) listAds() (adsResponse, error) {
 	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads", nil)
 	if err != nil {
@@ -188,11 +453,18 @@ func (tc *testClient) listAds() (adsResponse, error) {
 	return response, nil
 }
 
+// createUser регистрирует пользователя и запоминает его токен; при id == 0 ID выделяет сервер.
 func (tc *testClient) createUser(id int64, nickname, email string) (userResponse, error) {
+	return tc.createUserAs(0, id, nickname, email)
+}
+
+// createUserAs регистрирует пользователя от имени пользователя callerID, например администратора.
+func (tc *testClient) createUserAs(callerID, id int64, nickname, email string) (userResponse, error) {
 	body := map[string]any{
 		"nickname": nickname,
 		"email":    email,
 		"user_id":  id,
+		"password": testPassword,
 	}
 
 	data, err := json.Marshal(body)
@@ -206,6 +478,7 @@ func (tc *testClient) createUser(id int64, nickname, email string) (userResponse
 	}
 
 	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, callerID)
 
 	var response userResponse
 	err = tc.getResponse(req, &response)
@@ -213,26 +486,120 @@ func (tc *testClient) createUser(id int64, nickname, email string) (userResponse
 		return userResponse{}, err
 	}
 
+	login, err := tc.login(response.Data.ID, testPassword)
+	if err != nil {
+		return userResponse{}, err
+	}
+	tc.tokens[response.Data.ID] = login.Data.Token
+
 	return response, nil
 }
 
-func (tc *testClient) deleteAd(userID, adID int64) (adResponse, error) {
+func (tc *testClient) login(userID int64, password string) (loginResponse, error) {
 	body := map[string]any{
-		"user_id": userID,
+		"user_id":  userID,
+		"password": password,
 	}
 
 	data, err := json.Marshal(body)
 	if err != nil {
-		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+		return loginResponse{}, fmt.Errorf("unable to marshal: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), bytes.NewReader(data))
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/login", bytes.NewReader(data))
 	if err != nil {
-		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+		return loginResponse{}, fmt.Errorf("unable to create request: %w", err)
 	}
 
 	req.Header.Add("Content-Type", "application/json")
 
+	var response loginResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return loginResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) deleteAd(userID, adID int64) (adResponse, error) {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), nil)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+// favorite добавляет (method POST) или удаляет (DELETE) объявление из избранного
+// пользователя ownerID от имени пользователя userID.
+func (tc *testClient) favorite(method string, userID, ownerID, adID int64) (adResponse, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/favorites/%d", ownerID, adID), nil)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listFavorites(userID, ownerID int64) (adsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/favorites", ownerID), nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listDeletedAds(adminID int64) (adsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/admin/ads/deleted", nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, adminID)
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) restoreAd(adminID, adID int64) (adResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/admin/ads/%d/restore", adID), nil)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, adminID)
+
 	var response adResponse
 	err = tc.getResponse(req, &response)
 	if err != nil {
@@ -242,12 +609,32 @@ func (tc *testClient) deleteAd(userID, adID int64) (adResponse, error) {
 	return response, nil
 }
 
+func (tc *testClient) restoreUser(adminID, userID int64) (userResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/admin/users/%d/restore", userID), nil)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, adminID)
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
 func (tc *testClient) changeUserInfo(userID int64, nickname, email string) (userResponse, error) {
-	body := map[string]any{
+	return tc.changeUserInfoWith(userID, map[string]any{
 		"nickname": nickname,
 		"email":    email,
-	}
+	})
+}
 
+// changeUserInfoWith отправляет тело запроса как есть, например с полями профиля.
+func (tc *testClient) changeUserInfoWith(userID int64, body map[string]any) (userResponse, error) {
 	data, err := json.Marshal(body)
 	if err != nil {
 		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
@@ -259,6 +646,7 @@ func (tc *testClient) changeUserInfo(userID int64, nickname, email string) (user
 	}
 
 	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
 
 	var response userResponse
 	err = tc.getResponse(req, &response)
@@ -269,12 +657,52 @@ func (tc *testClient) changeUserInfo(userID int64, nickname, email string) (user
 	return response, nil
 }
 
+func (tc *testClient) getUser(userID int64) (userResponse, error) {
+	return tc.getUserAs(0, userID)
+}
+
+// getUserAs запрашивает профиль от имени callerID; 0 - анонимный запрос.
+func (tc *testClient) getUserAs(callerID, userID int64) (userResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d", userID), nil)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	if callerID != 0 {
+		tc.authorize(req, callerID)
+	}
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listUserAds(userID int64, query url.Values) (adsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/ads?", userID)+query.Encode(), nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
 func (tc *testClient) deleteUserByID(userID int64) (userResponse, error) {
 	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d", userID), nil)
 	if err != nil {
 		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
 	}
 
+	tc.authorize(req, userID)
+
 	var response userResponse
 	err = tc.getResponse(req, &response)
 	if err != nil {
@@ -314,3 +742,138 @@ func (tc *testClient) listAdsAuthor(authorId int64) (adsResponse, error) {
 
 	return response, nil
 }
+
+func (tc *testClient) listAdsQuery(query url.Values) (adsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads?"+query.Encode(), nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listCategories() (categoriesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/categories", nil)
+	if err != nil {
+		return categoriesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response categoriesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return categoriesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) searchAds(query string) (searchResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads/search?"+url.Values{"q": {query}}.Encode(), nil)
+	if err != nil {
+		return searchResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response searchResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return searchResponse{}, err
+	}
+
+	return response, nil
+}
+
+// contactSeller пишет автору объявления adID от имени пользователя userID.
+func (tc *testClient) contactSeller(userID, adID int64, text string) (messageResponse, error) {
+	return tc.postMessage(userID, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/messages", adID), text)
+}
+
+func (tc *testClient) sendMessage(userID, threadID int64, text string) (messageResponse, error) {
+	return tc.postMessage(userID, fmt.Sprintf(tc.baseURL+"/api/v1/threads/%d/messages", threadID), text)
+}
+
+func (tc *testClient) postMessage(userID int64, url string, text string) (messageResponse, error) {
+	data, err := json.Marshal(map[string]any{"text": text})
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
+
+	var response messageResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messageResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listMessages(userID, threadID int64) (messagesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/threads/%d/messages", threadID), nil)
+	if err != nil {
+		return messagesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response messagesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messagesResponse{}, err
+	}
+
+	return response, nil
+}
+
+// markRead отмечает прочитанными сообщения с ID не больше upTo, при upTo == 0 - все.
+func (tc *testClient) markRead(userID, threadID, upTo int64) (messagesResponse, error) {
+	data, err := json.Marshal(map[string]any{"up_to": upTo})
+	if err != nil {
+		return messagesResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/threads/%d/read", threadID), bytes.NewReader(data))
+	if err != nil {
+		return messagesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
+
+	var response messagesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messagesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listThreads(userID, ownerID int64) (threadsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/threads", ownerID), nil)
+	if err != nil {
+		return threadsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response threadsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return threadsResponse{}, err
+	}
+
+	return response, nil
+}
@@ -11,7 +11,7 @@ func TestCreateAd_EmptyTitle(t *testing.T) {
 	client := getTestClient()
 	_, _ = client.createUser(123, "user", "somemail@mail.com")
 	_, err := client.createAd(123, "", "world")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnprocessable)
 }
 
 func TestCreateAd_TooLongTitle(t *testing.T) {
@@ -20,14 +20,14 @@ func TestCreateAd_TooLongTitle(t *testing.T) {
 	title := strings.Repeat("a", 101)
 	_, _ = client.createUser(123, "user", "somemail@mail.com")
 	_, err := client.createAd(123, title, "world")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnprocessable)
 }
 
 func TestCreateAd_EmptyText(t *testing.T) {
 	client := getTestClient()
 	_, _ = client.createUser(123, "user", "somemail@mail.com")
 	_, err := client.createAd(123, "title", "")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnprocessable)
 }
 
 func TestCreateAd_TooLongText(t *testing.T) {
@@ -36,7 +36,7 @@ func TestCreateAd_TooLongText(t *testing.T) {
 	text := strings.Repeat("a", 501)
 	_, _ = client.createUser(123, "user", "somemail@mail.com")
 	_, err := client.createAd(123, "title", text)
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnprocessable)
 }
 
 func TestUpdateAd_EmptyTitle(t *testing.T) {
@@ -46,7 +46,7 @@ func TestUpdateAd_EmptyTitle(t *testing.T) {
 	assert.NoError(t, err)
 
 	_, err = client.updateAd(123, resp.Data.ID, "", "new_world")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnprocessable)
 }
 
 func TestUpdateAd_TooLongTitle(t *testing.T) {
@@ -58,7 +58,7 @@ func TestUpdateAd_TooLongTitle(t *testing.T) {
 	title := strings.Repeat("a", 101)
 
 	_, err = client.updateAd(123, resp.Data.ID, title, "world")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnprocessable)
 }
 
 func TestUpdateAd_EmptyText(t *testing.T) {
@@ -68,7 +68,7 @@ func TestUpdateAd_EmptyText(t *testing.T) {
 	assert.NoError(t, err)
 
 	_, err = client.updateAd(123, resp.Data.ID, "title", "")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnprocessable)
 }
 
 func TestUpdateAd_TooLongText(t *testing.T) {
@@ -80,5 +80,5 @@ func TestUpdateAd_TooLongText(t *testing.T) {
 	assert.NoError(t, err)
 
 	_, err = client.updateAd(123, resp.Data.ID, "title", text)
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnprocessable)
 }
@@ -1,7 +1,29 @@
 package user
 
+import "time"
+
 type User struct {
-	ID       int64
-	Nickname string
-	Email    string
+	ID int64
+	// Nickname начинается с буквы и состоит из букв, цифр и символов "_", ".", "-".
+	// Никнейм и email уникальны без учёта регистра
+	Nickname string `validate:"range:3,30;nickname"`
+	Email    string `validate:"range:3,254;email"`
+	Profile
+	RegistrationDate time.Time
+	Rating           Rating
+	// DeletedAt задан у удалённого пользователя: он хранится до окончательной очистки
+	DeletedAt *time.Time
+}
+
+// Profile - необязательные сведения, которые пользователь указывает о себе сам.
+type Profile struct {
+	DisplayName string `validate:"range:0,60"`
+	// AvatarURL - ссылка http или https на изображение
+	AvatarURL string `validate:"range:0,2048;url"`
+}
+
+// Rating - средняя оценка пользователя от 1 до 5 и число оценок; без оценок Score равен 0.
+type Rating struct {
+	Score float64
+	Votes int64
 }
@@ -0,0 +1 @@
+DROP TABLE ads;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE ads (
+    id            bigint generated by default as identity (minvalue 0 start with 0) primary key,
+    title         text        not null,
+    text          text        not null,
+    author_id     bigint      not null,
+    published     boolean     not null default false,
+    creation_date timestamptz not null,
+    update_date   timestamptz not null
+);
+
+CREATE INDEX ads_author_id_idx ON ads (author_id);
+CREATE INDEX ads_title_idx ON ads (title);
@@ -0,0 +1 @@
+DROP INDEX ads_search_idx;
\ No newline at end of file
@@ -0,0 +1,3 @@
+CREATE INDEX ads_search_idx ON ads USING gin (
+    (setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', text), 'B'))
+);
@@ -0,0 +1,2 @@
+ALTER TABLE ads DROP COLUMN reject_reason;
+ALTER TABLE ads DROP COLUMN status;
\ No newline at end of file
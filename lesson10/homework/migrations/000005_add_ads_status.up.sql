@@ -0,0 +1,3 @@
+ALTER TABLE ads ADD COLUMN status text not null default 'draft';
+ALTER TABLE ads ADD COLUMN reject_reason text not null default '';
+UPDATE ads SET status = 'published' WHERE published;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE ad_revisions;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE ad_revisions (
+    ad_id         bigint      not null references ads (id) on delete cascade,
+    number        bigint      not null,
+    title         text        not null,
+    text          text        not null,
+    editor_id     bigint      not null,
+    creation_date timestamptz not null,
+    primary key (ad_id, number)
+);
+
+-- Текущее содержимое существующих объявлений становится их первой версией
+INSERT INTO ad_revisions (ad_id, number, title, text, editor_id, creation_date)
+SELECT id, 1, title, text, author_id, update_date FROM ads;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE ads DROP COLUMN version;
\ No newline at end of file
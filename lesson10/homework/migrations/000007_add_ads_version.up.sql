@@ -0,0 +1 @@
+ALTER TABLE ads ADD COLUMN version bigint not null default 1;
\ No newline at end of file
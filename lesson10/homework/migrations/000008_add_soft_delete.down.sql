@@ -0,0 +1,4 @@
+DROP INDEX users_deleted_at_idx;
+DROP INDEX ads_deleted_at_idx;
+ALTER TABLE users DROP COLUMN deleted_at;
+ALTER TABLE ads DROP COLUMN deleted_at;
\ No newline at end of file
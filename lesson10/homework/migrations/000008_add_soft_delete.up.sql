@@ -0,0 +1,6 @@
+ALTER TABLE ads ADD COLUMN deleted_at timestamptz;
+ALTER TABLE users ADD COLUMN deleted_at timestamptz;
+
+-- Для очистки: удалённых записей немного по сравнению с действующими
+CREATE INDEX ads_deleted_at_idx ON ads (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
\ No newline at end of file
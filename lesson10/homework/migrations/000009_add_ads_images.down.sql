@@ -0,0 +1 @@
+ALTER TABLE ads DROP COLUMN images;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE ads ADD COLUMN images jsonb not null default '[]';
\ No newline at end of file
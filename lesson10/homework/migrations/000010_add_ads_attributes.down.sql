@@ -0,0 +1,6 @@
+ALTER TABLE ads
+    DROP COLUMN category,
+    DROP COLUMN tags,
+    DROP COLUMN price_amount,
+    DROP COLUMN price_currency,
+    DROP COLUMN location;
\ No newline at end of file
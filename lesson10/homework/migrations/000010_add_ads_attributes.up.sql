@@ -0,0 +1,11 @@
+ALTER TABLE ads
+    ADD COLUMN category text not null default '',
+    ADD COLUMN tags text[] not null default '{}',
+    ADD COLUMN price_amount bigint not null default 0,
+    ADD COLUMN price_currency text not null default '',
+    ADD COLUMN location text not null default '';
+
+-- text_pattern_ops нужен для поиска по префиксу: категория выбирается вместе с вложенными
+CREATE INDEX ads_category_idx ON ads (category text_pattern_ops);
+CREATE INDEX ads_tags_idx ON ads USING gin (tags);
+CREATE INDEX ads_price_idx ON ads (price_currency, price_amount);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE favorites;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE favorites (
+    user_id       bigint      not null references users (id) on delete cascade,
+    ad_id         bigint      not null references ads (id) on delete cascade,
+    creation_date timestamptz not null,
+    primary key (user_id, ad_id)
+);
+
+-- Для очистки избранного при удалении объявления
+CREATE INDEX favorites_ad_id_idx ON favorites (ad_id);
\ No newline at end of file
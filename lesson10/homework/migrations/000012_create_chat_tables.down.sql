@@ -0,0 +1,2 @@
+DROP TABLE messages;
+DROP TABLE threads;
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE threads (
+    id            bigint      generated by default as identity primary key,
+    ad_id         bigint      not null references ads (id) on delete cascade,
+    buyer_id      bigint      not null references users (id) on delete cascade,
+    seller_id     bigint      not null references users (id) on delete cascade,
+    creation_date timestamptz not null,
+    update_date   timestamptz not null,
+    unique (ad_id, buyer_id)
+);
+
+CREATE INDEX threads_buyer_id_idx ON threads (buyer_id);
+CREATE INDEX threads_seller_id_idx ON threads (seller_id);
+
+CREATE TABLE messages (
+    id            bigint      generated by default as identity primary key,
+    thread_id     bigint      not null references threads (id) on delete cascade,
+    sender_id     bigint      not null,
+    text          text        not null,
+    read_at       timestamptz,
+    creation_date timestamptz not null
+);
+
+CREATE INDEX messages_thread_id_idx ON messages (thread_id, id);
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX users_email_key;
+DROP INDEX users_nickname_key;
+ALTER TABLE users ALTER COLUMN id DROP DEFAULT;
+DROP SEQUENCE users_id_seq;
\ No newline at end of file
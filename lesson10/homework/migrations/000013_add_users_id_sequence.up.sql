@@ -0,0 +1,8 @@
+-- ID пользователей выделяет сервер; заданные при импорте ID сдвигают последовательность
+CREATE SEQUENCE users_id_seq OWNED BY users.id;
+SELECT setval('users_id_seq', COALESCE((SELECT max(id) FROM users), 0) + 1, false);
+ALTER TABLE users ALTER COLUMN id SET DEFAULT nextval('users_id_seq');
+
+-- Удалённые пользователи занимают никнейм и email до очистки
+CREATE UNIQUE INDEX users_nickname_key ON users (lower(nickname));
+CREATE UNIQUE INDEX users_email_key ON users (lower(email));
\ No newline at end of file
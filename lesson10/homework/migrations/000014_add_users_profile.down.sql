@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN rating_votes;
+ALTER TABLE users DROP COLUMN rating_score;
+ALTER TABLE users DROP COLUMN registration_date;
+ALTER TABLE users DROP COLUMN avatar_url;
+ALTER TABLE users DROP COLUMN display_name;
\ No newline at end of file
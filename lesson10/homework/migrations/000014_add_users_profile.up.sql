@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN display_name text not null default '';
+ALTER TABLE users ADD COLUMN avatar_url text not null default '';
+-- Для пользователей, зарегистрированных раньше, точное время неизвестно
+ALTER TABLE users ADD COLUMN registration_date timestamptz not null default now();
+ALTER TABLE users ADD COLUMN rating_score double precision not null default 0;
+ALTER TABLE users ADD COLUMN rating_votes bigint not null default 0;
\ No newline at end of file